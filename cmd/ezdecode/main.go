@@ -0,0 +1,85 @@
+// ezdecode prints the records of MOD_BINARY log files as json or logfmt
+// lines. It reads stdin when no file is given.
+//
+//	ezdecode [-format json|logfmt] [-ts s|ms|ns|rfc3339nano] access.bin
+//
+// Timestamps are RFC3339 with nanoseconds unless -ts asks for the unix
+// seconds, milliseconds or nanoseconds of the other TimestampFormats.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/skadilover/easykit/log"
+)
+
+var timestampFormats = map[string]int{
+	"s":           log.TS_SECOND,
+	"ms":          log.TS_MILLI,
+	"ns":          log.TS_NANO,
+	"rfc3339nano": log.TS_RFC3339NANO,
+}
+
+func main() {
+	format := flag.String("format", "json", "output format: json or logfmt")
+	tsFormat := flag.String("ts", "rfc3339nano", "timestamp format: s, ms, ns or rfc3339nano")
+	flag.Parse()
+
+	mode := log.MOD_JSON
+	switch *format {
+	case "json":
+	case "logfmt":
+		mode = log.MOD_LOGFMT
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %s\n", *format)
+		os.Exit(2)
+	}
+	ts, ok := timestampFormats[*tsFormat]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown timestamp format %s\n", *tsFormat)
+		os.Exit(2)
+	}
+	enc := log.NewTimestampEncoder(mode, ts)
+
+	if flag.NArg() == 0 {
+		if err := decode(os.Stdin, enc); err != nil {
+			fmt.Fprintf(os.Stderr, "decode stdin failed:%s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "open %s failed:%s\n", name, err.Error())
+			os.Exit(1)
+		}
+		err = decode(f, enc)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "decode %s failed:%s\n", name, err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+func decode(r io.Reader, enc log.Encoder) error {
+	d := log.NewBinaryDecoder(r)
+	for {
+		o, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, err := enc.Encode(o)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(line)
+	}
+}
@@ -0,0 +1,178 @@
+// ezlog reads the MOD_JSON or MOD_BINARY files of the logger, their
+// rotated and gzipped backups included, filters the records and prints
+// them as text lines or as they are.
+//
+//	ezlog [-logid id] [-level warn] [-module /api/*] [-tag t]
+//	      [-since 1h] [-until 2020-01-02T15:04:05Z] [-f] [-raw] test.json
+//
+// -since and -until take a duration back from now, RFC3339 or a local
+// "2006-01-02 15:04:05" or "2006-01-02" time. -f follows the current
+// file across rotation like tail -F.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skadilover/easykit/log"
+)
+
+type filter struct {
+	logid  string
+	level  int
+	module string
+	tag    string
+	since  time.Time
+	until  time.Time
+}
+
+func (f *filter) match(r *record) bool {
+	if f.logid != "" && r.logid != f.logid && !strings.HasPrefix(r.logid, f.logid+"###") {
+		return false
+	}
+	if level, err := log.ParseLevel(r.level); err == nil && level < f.level {
+		return false
+	}
+	if f.module != "" {
+		if prefix, ok := strings.CutSuffix(f.module, "*"); ok {
+			if !strings.HasPrefix(r.module, prefix) {
+				return false
+			}
+		} else if r.module != f.module {
+			return false
+		}
+	}
+	if f.tag != "" && r.tag != f.tag {
+		return false
+	}
+	if !f.since.IsZero() && r.time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && r.time.After(f.until) {
+		return false
+	}
+	return true
+}
+
+// output prints the matching records, it is shared by the files
+// followed concurrently.
+type output struct {
+	mu     sync.Mutex
+	filter *filter
+	raw    bool
+}
+
+func (o *output) print(r *record) {
+	if !o.filter.match(r) {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.raw {
+		os.Stdout.Write(r.rawLine())
+		return
+	}
+	os.Stdout.WriteString(r.pretty())
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", log.DATEFORMAT} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time %q", s)
+}
+
+func main() {
+	f := &filter{}
+	flag.StringVar(&f.logid, "logid", "", "records of the logid, the ###cid suffix may be left out")
+	level := flag.String("level", "debug", "minimum level")
+	flag.StringVar(&f.module, "module", "", "records of the module, a trailing * matches a prefix")
+	flag.StringVar(&f.tag, "tag", "", "records of the tag")
+	since := flag.String("since", "", "records at or after, e.g. 1h or 2006-01-02 15:04:05")
+	until := flag.String("until", "", "records at or before")
+	follow := flag.Bool("f", false, "follow the current files")
+	rotated := flag.Bool("rotated", true, "read the rotated files first")
+	binary := flag.Bool("binary", false, "the files are MOD_BINARY")
+	raw := flag.Bool("raw", false, "print records as json lines instead of text")
+	flag.Parse()
+
+	var err error
+	if f.level, err = log.ParseLevel(*level); err != nil {
+		fail(err)
+	}
+	if f.since, err = parseTime(*since); err != nil {
+		fail(err)
+	}
+	if f.until, err = parseTime(*until); err != nil {
+		fail(err)
+	}
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ezlog [flags] file...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	out := &output{filter: f, raw: *raw}
+	read := readJson
+	if *binary {
+		read = readBinary
+	}
+	var wg sync.WaitGroup
+	for _, name := range flag.Args() {
+		files := []string{name}
+		if *rotated {
+			files = append(backups(name), name)
+		}
+		for _, file := range files[:len(files)-1] {
+			//the cleanup of the logger may have compressed or
+			//removed the backup since it was listed
+			err := readFile(file, read, out)
+			if errors.Is(err, fs.ErrNotExist) && !strings.HasSuffix(file, ".gz") {
+				err = readFile(file+".gz", read, out)
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				fail(err)
+			}
+		}
+		if !*follow {
+			if err := readFile(name, read, out); err != nil {
+				fail(err)
+			}
+			continue
+		}
+		fr, err := newFollowReader(name)
+		if err != nil {
+			fail(err)
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := read(fr, out); err != nil {
+				fmt.Fprintf(os.Stderr, "follow %s failed:%s\n", name, err.Error())
+			}
+		}(name)
+	}
+	wg.Wait()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skadilover/easykit/log"
+)
+
+func Test_filterMatch(t *testing.T) {
+	now := time.Now()
+	r := &record{time: now, level: "WARN", logid: "abc###1", module: "/api/user", tag: "db"}
+	cases := []struct {
+		name   string
+		filter filter
+		want   bool
+	}{
+		{"empty", filter{}, true},
+		{"logid", filter{logid: "abc###1"}, true},
+		{"logid without cid", filter{logid: "abc"}, true},
+		{"logid prefix", filter{logid: "ab"}, false},
+		{"level below", filter{level: log.WARN}, true},
+		{"level above", filter{level: log.ERROR}, false},
+		{"module", filter{module: "/api/user"}, true},
+		{"module other", filter{module: "/api"}, false},
+		{"module prefix", filter{module: "/api/*"}, true},
+		{"module other prefix", filter{module: "/admin/*"}, false},
+		{"tag", filter{tag: "db"}, true},
+		{"tag other", filter{tag: "cache"}, false},
+		{"since", filter{since: now.Add(-time.Second)}, true},
+		{"since after", filter{since: now.Add(time.Second)}, false},
+		{"until", filter{until: now}, true},
+		{"until before", filter{until: now.Add(-time.Second)}, false},
+	}
+	for _, c := range cases {
+		if got := c.filter.match(r); got != c.want {
+			t.Errorf("%s: expect %v, got %v", c.name, c.want, got)
+		}
+	}
+}
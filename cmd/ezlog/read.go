@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skadilover/easykit/log"
+)
+
+// how often a followed file is checked for new records and rotation
+const pollInterval = 200 * time.Millisecond
+
+// backups returns the rotated files of name, oldest first. They are
+// named name.YYYY-MM-DD[-HH][_N], gzipped ones end with .gz.
+func backups(name string) []string {
+	matches, _ := filepath.Glob(name + ".[0-9]*")
+	type backup struct {
+		path   string
+		period string
+		n      int
+	}
+	var files []backup
+	for _, m := range matches {
+		if strings.HasSuffix(m, ".tmp") {
+			continue
+		}
+		if fi, err := os.Stat(m); err != nil || fi.IsDir() {
+			continue
+		}
+		b := backup{path: m, n: -1}
+		b.period = strings.TrimSuffix(strings.TrimPrefix(m, name+"."), ".gz")
+		if i := strings.LastIndexByte(b.period, '_'); i >= 0 {
+			if n, err := strconv.Atoi(b.period[i+1:]); err == nil {
+				b.period, b.n = b.period[:i], n
+			}
+		}
+		files = append(files, b)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].period != files[j].period {
+			return files[i].period < files[j].period
+		}
+		return files[i].n < files[j].n
+	})
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+func readFile(name string, read func(io.Reader, *output) error, out *output) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return read(r, out)
+}
+
+// readJson prints the MOD_JSON records of r, other lines are skipped.
+func readJson(r io.Reader, out *output) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if rec, ok := parseJson(line); ok {
+				out.print(rec)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readBinary(r io.Reader, out *output) error {
+	d := log.NewBinaryDecoder(bufio.NewReader(r))
+	for {
+		o, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out.print(fromObject(o))
+	}
+}
+
+// followReader reads a file without ever returning io.EOF. Once the
+// file at the path is another one, because the logger or logrotate
+// rotated it, the rest of the old file is read and the new one opened.
+// A truncated file is read again from the start.
+type followReader struct {
+	path string
+	f    *os.File
+}
+
+func newFollowReader(path string) (*followReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &followReader{path: path, f: f}, nil
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if !r.switched() {
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
+// switched checks the path at the end of the current file, it reports
+// whether reading can go on right away.
+func (r *followReader) switched() bool {
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		//between the rename and the creation of the new file
+		return false
+	}
+	cur, err := r.f.Stat()
+	off, serr := r.f.Seek(0, io.SeekCurrent)
+	if err == nil && os.SameFile(fi, cur) {
+		if serr == nil && fi.Size() < off {
+			r.f.Seek(0, io.SeekStart)
+			return true
+		}
+		return false
+	}
+	//written to the old file between its last read and the rename
+	if err == nil && serr == nil && cur.Size() > off {
+		return true
+	}
+	f, err := os.Open(r.path)
+	if err != nil {
+		return false
+	}
+	r.f.Close()
+	r.f = f
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_backups(t *testing.T) {
+	cases := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"none", nil, nil},
+		{"daily", []string{"2020-01-02", "2020-01-01", "2020-01-03.gz"}, []string{"2020-01-01", "2020-01-02", "2020-01-03.gz"}},
+		{"hourly", []string{"2020-01-01-10", "2020-01-01-09"}, []string{"2020-01-01-09", "2020-01-01-10"}},
+		{"numbered", []string{"2020-01-01_10.gz", "2020-01-01_2", "2020-01-01", "2020-01-01_0"}, []string{"2020-01-01", "2020-01-01_0", "2020-01-01_2", "2020-01-01_10.gz"}},
+		{"temporary", []string{"2020-01-01", "2020-01-02.gz.tmp"}, []string{"2020-01-01"}},
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "app")
+		for _, f := range c.files {
+			os.WriteFile(name+"."+f, nil, 0644)
+		}
+		//neither is a backup
+		os.WriteFile(name+".access", nil, 0644)
+		os.Mkdir(name+".2020-12-31", 0755)
+		var want []string
+		for _, f := range c.want {
+			want = append(want, name+"."+f)
+		}
+		if got := backups(name); len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("%s: expect %v, got %v", c.name, want, got)
+		}
+	}
+}
+
+func Test_followReaderSwitched(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(path string)
+		want   bool
+		next   string
+	}{
+		{"unchanged", func(path string) {}, false, ""},
+		{"missing", func(path string) { os.Rename(path, path+".1") }, false, ""},
+		{"rotated", func(path string) {
+			os.Rename(path, path+".1")
+			os.WriteFile(path, []byte("new\n"), 0644)
+		}, true, "new\n"},
+		{"rotated after a write", func(path string) {
+			f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			f.WriteString("last\n")
+			f.Close()
+			os.Rename(path, path+".1")
+			os.WriteFile(path, []byte("new\n"), 0644)
+		}, true, "last\n"},
+		{"truncated", func(path string) { os.WriteFile(path, []byte("x\n"), 0644) }, true, "x\n"},
+	}
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "app.json")
+		os.WriteFile(path, []byte("old line\n"), 0644)
+		r, err := newFollowReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(r.f)
+		c.change(path)
+		if got := r.switched(); got != c.want {
+			t.Errorf("%s: expect %v, got %v", c.name, c.want, got)
+		}
+		if data, _ := io.ReadAll(r.f); string(data) != c.next {
+			t.Errorf("%s: expect %q next, got %q", c.name, c.next, data)
+		}
+		r.f.Close()
+	}
+}
+
+func Test_followRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	os.WriteFile(path, []byte("one\n"), 0644)
+	r, err := newFollowReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { r.f.Close() }()
+	read := make(chan string)
+	go func() {
+		buf := make([]byte, len("one\ntwo\nthree\n"))
+		n, _ := io.ReadFull(r, buf)
+		read <- string(buf[:n])
+	}()
+	//written after the reader reached the end of the file
+	time.Sleep(2 * pollInterval)
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("two\n")
+	f.Close()
+	os.Rename(path, path+".2020-01-01")
+	os.WriteFile(path, []byte("three\n"), 0644)
+	select {
+	case got := <-read:
+		if got != "one\ntwo\nthree\n" {
+			t.Errorf("unexpected records %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("new file not followed")
+	}
+}
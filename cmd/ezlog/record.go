@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skadilover/easykit/log"
+)
+
+// record is a LogObject read back from a file, keys other than those
+// of LogObject are fields.
+type record struct {
+	time   time.Time
+	level  string
+	logid  string
+	module string
+	tag    string
+	file   string
+	line   int
+	msg    interface{}
+	stack  []string
+	fields map[string]interface{}
+	//the json line the record was read from, nil for MOD_BINARY
+	raw []byte
+	obj *log.LogObject
+}
+
+var objectKeys = map[string]bool{
+	"timestamp": true, "level": true, "logid": true, "product": true,
+	"module": true, "caller_ip": true, "host_ip": true, "msg": true,
+	"trace": true, "tag": true,
+}
+
+// parseJson reads a MOD_JSON line, the other lines are not records.
+func parseJson(line []byte) (*record, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return nil, false
+	}
+	r := &record{
+		time:   parseTimestamp(m["timestamp"]),
+		level:  str(m["level"]),
+		logid:  str(m["logid"]),
+		module: str(m["module"]),
+		tag:    str(m["tag"]),
+		msg:    m["msg"],
+		fields: make(map[string]interface{}),
+		raw:    append(line, '\n'),
+	}
+	if trace, ok := m["trace"].(map[string]interface{}); ok {
+		r.file = str(trace["File"])
+		if n, ok := trace["Line"].(json.Number); ok {
+			line, _ := n.Int64()
+			r.line = int(line)
+		}
+		if stack, ok := trace["Stack"].([]interface{}); ok {
+			for _, frame := range stack {
+				r.stack = append(r.stack, str(frame))
+			}
+		}
+	}
+	for k, v := range m {
+		if !objectKeys[k] {
+			r.fields[k] = v
+		}
+	}
+	return r, true
+}
+
+// parseTimestamp accepts every TimestampFormat, numbers are told apart
+// by their magnitude.
+func parseTimestamp(v interface{}) time.Time {
+	switch v := v.(type) {
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}
+		}
+		switch a := math.Abs(float64(n)); {
+		case a >= 1e17:
+			return time.Unix(0, n)
+		case a >= 1e11:
+			return time.UnixMilli(n)
+		default:
+			return time.Unix(n, 0)
+		}
+	}
+	return time.Time{}
+}
+
+func fromObject(o *log.LogObject) *record {
+	r := &record{
+		time:   o.Time,
+		level:  o.Level,
+		logid:  o.Logid,
+		module: o.Module,
+		tag:    o.Tag,
+		msg:    o.Msg,
+		fields: make(map[string]interface{}, len(o.Fields)),
+		obj:    o,
+	}
+	if r.time.IsZero() {
+		r.time = time.Unix(o.Timestamp, 0)
+	}
+	r.file = str(o.Trace["File"])
+	switch line := o.Trace["Line"].(type) {
+	case float64:
+		r.line = int(line)
+	case int:
+		r.line = line
+	}
+	if stack, ok := o.Trace["Stack"].([]interface{}); ok {
+		for _, frame := range stack {
+			r.stack = append(r.stack, str(frame))
+		}
+	}
+	for _, f := range o.Fields {
+		r.fields[f.Key] = f.Value
+	}
+	return r
+}
+
+func str(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var jsonEncoder = log.NewEncoder(log.MOD_JSON)
+
+func (r *record) rawLine() []byte {
+	if r.raw != nil {
+		return r.raw
+	}
+	line, err := jsonEncoder.Encode(r.obj)
+	if err != nil {
+		return []byte(fmt.Sprintf("{\"error\":%q}\n", err.Error()))
+	}
+	return line
+}
+
+// pretty formats r as
+// "2006-01-02 15:04:05.000 LEVEL [logid] module file:line [tag] msg k=v"
+// followed by the stack, one frame per line.
+func (r *record) pretty() string {
+	var b strings.Builder
+	b.WriteString(r.time.Local().Format("2006-01-02 15:04:05.000"))
+	fmt.Fprintf(&b, " %-5s [%s]", r.level, r.logid)
+	if r.module != "" {
+		b.WriteString(" " + r.module)
+	}
+	if r.file != "" {
+		b.WriteString(" " + r.file + ":" + strconv.Itoa(r.line))
+	}
+	if r.tag != "" {
+		b.WriteString(" [" + r.tag + "]")
+	}
+	b.WriteString(" " + value(r.msg, false))
+	keys := make([]string, 0, len(r.fields))
+	for k := range r.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(" " + k + "=" + value(r.fields[k], true))
+	}
+	b.WriteByte('\n')
+	for _, frame := range r.stack {
+		b.WriteString("\t" + frame + "\n")
+	}
+	return b.String()
+}
+
+// value renders strings as they are, quoted when they are a field
+// value containing spaces, and other values as json.
+func value(v interface{}, quote bool) string {
+	switch v := v.(type) {
+	case string:
+		if quote && (v == "" || strings.ContainsAny(v, " =\"")) {
+			return strconv.Quote(v)
+		}
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_parseTimestamp(t *testing.T) {
+	at := time.Date(2020, 1, 2, 15, 4, 5, 123456789, time.UTC)
+	cases := []struct {
+		v    interface{}
+		want time.Time
+	}{
+		{"2020-01-02T15:04:05.123456789Z", at},
+		{json.Number("1577977445"), at.Truncate(time.Second)},
+		{json.Number("1577977445123"), at.Truncate(time.Millisecond)},
+		{json.Number("1577977445123456789"), at},
+		{json.Number("0"), time.Unix(0, 0)},
+		{json.Number("1.5"), time.Time{}},
+		{"yesterday", time.Time{}},
+		{nil, time.Time{}},
+	}
+	for _, c := range cases {
+		if got := parseTimestamp(c.v); !got.Equal(c.want) {
+			t.Errorf("%v: expect %v, got %v", c.v, c.want, got)
+		}
+	}
+}
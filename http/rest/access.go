@@ -0,0 +1,191 @@
+package rest
+
+import (
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "github.com/skadilover/easykit/log"
+)
+
+//access log formats
+const (
+    //host - - [time] "request" status bytes, then in=bytes rt=seconds logid=id,
+    //the query values of the request are redacted by the rules of the logger
+    AccessCommon = iota
+    //AccessCommon with "referer" "user agent" before in=
+    AccessCombined
+    //Infow entry "access" with the fields method, path, status, latency_ms,
+    //bytes_in, bytes_out and user_agent, logid and caller ip in the header
+    AccessJson
+)
+
+const accessTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+type accessLog struct {
+    l      *log.BaseLogger
+    format int
+}
+
+var access atomic.Pointer[accessLog]
+
+//SetAccessLog makes the routes of MakeRoute and MakeRouteForm write one
+//line per request to l in format, usually l is a logger of its own, see
+//NewAccessLogger. A nil l turns the access log off.
+func SetAccessLog(l *log.BaseLogger, format int) {
+    if l == nil {
+        access.Store(nil)
+        return
+    }
+    access.Store(&accessLog{l: l, format: format})
+}
+
+//NewAccessLogger creates the logger of c for SetAccessLog. For AccessCommon
+//and AccessCombined lines are written as they are unless c has a Pattern,
+//AccessJson uses log.MOD_JSON. The logger is returned even with an error,
+//see log.NewBaseLogger.
+func NewAccessLogger(c *log.LogConfig, format int) (*log.BaseLogger, error) {
+    ac := *c
+    if format == AccessJson {
+        ac.Mode = log.MOD_JSON
+    } else if ac.Pattern == "" {
+        ac.Pattern = "%msg"
+    }
+    return log.NewBaseLogger(&ac)
+}
+
+//accessWriter records the status and size of the response.
+type accessWriter struct {
+    http.ResponseWriter
+    status int
+    bytes  int64
+}
+
+func (w *accessWriter) WriteHeader(code int) {
+    if w.status == 0 {
+        w.status = code
+    }
+    w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessWriter) Write(p []byte) (int, error) {
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+    n, err := w.ResponseWriter.Write(p)
+    w.bytes += int64(n)
+    return n, err
+}
+
+func (w *accessWriter) Flush() {
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+//for http.ResponseController
+func (w *accessWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+//accessBody counts the bytes of the request body read by the handler.
+type accessBody struct {
+    io.ReadCloser
+    bytes int64
+}
+
+func (b *accessBody) Read(p []byte) (int, error) {
+    n, err := b.ReadCloser.Read(p)
+    b.bytes += int64(n)
+    return n, err
+}
+
+//accessRequest is an access log line in the making.
+type accessRequest struct {
+    a     *accessLog
+    r     *http.Request
+    w     *accessWriter
+    body  *accessBody
+    start time.Time
+}
+
+//startAccess wraps w and the body of r when the access log is on, done
+//has to be called once the request is served.
+func startAccess(w http.ResponseWriter, r *http.Request) (*accessRequest, http.ResponseWriter) {
+    a := access.Load()
+    if a == nil {
+        return nil, w
+    }
+    ar := &accessRequest{a: a, r: r, w: &accessWriter{ResponseWriter: w}, start: time.Now()}
+    if r.Body != nil {
+        ar.body = &accessBody{ReadCloser: r.Body}
+        r.Body = ar.body
+    }
+    return ar, ar.w
+}
+
+func (ar *accessRequest) done(l log.Logger) {
+    if ar == nil {
+        return
+    }
+    latency := time.Since(ar.start)
+    r := ar.r
+    status := ar.w.status
+    if status == 0 {
+        status = http.StatusOK
+    }
+    in := r.ContentLength
+    if ar.body != nil && ar.body.bytes > in {
+        in = ar.body.bytes
+    }
+    if in < 0 {
+        in = 0
+    }
+    h := l.Head()
+    if h.CallerIp == "" {
+        h.CallerIp = r.RemoteAddr
+        if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+            h.CallerIp = host
+        }
+    }
+    if ar.a.format == AccessJson {
+        ar.a.l.Logger(h).Infow("access",
+            "method", r.Method,
+            "path", r.URL.Path,
+            "status", status,
+            "latency_ms", float64(latency.Microseconds())/1000,
+            "bytes_in", in,
+            "bytes_out", ar.w.bytes,
+            "user_agent", r.UserAgent(),
+        )
+        return
+    }
+    out := "-"
+    if ar.w.bytes > 0 {
+        out = strconv.FormatInt(ar.w.bytes, 10)
+    }
+    //query values go through the redaction rules of the access logger
+    uri := r.RequestURI
+    if path, query, ok := strings.Cut(uri, "?"); ok {
+        uri = path + "?" + ar.a.l.RedactQuery(query)
+    }
+    line := fmt.Sprintf("%s - - [%s] %q %d %s", h.CallerIp, ar.start.Format(accessTimeFormat),
+        r.Method+" "+uri+" "+r.Proto, status, out)
+    if ar.a.format == AccessCombined {
+        line += fmt.Sprintf(" %q %q", dash(r.Referer()), dash(r.UserAgent()))
+    }
+    line += fmt.Sprintf(" in=%d rt=%.3f logid=%s", in, latency.Seconds(), h.LogId)
+    ar.a.l.Logger(h).Info("%s", line)
+}
+
+func dash(s string) string {
+    if s == "" {
+        return "-"
+    }
+    return s
+}
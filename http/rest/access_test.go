@@ -0,0 +1,136 @@
+package rest
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/skadilover/easykit/log"
+)
+
+type memSink struct {
+    mu    sync.Mutex
+    lines []string
+}
+
+func (s *memSink) Write(level int, p []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.lines = append(s.lines, string(p))
+    return nil
+}
+
+func (s *memSink) Sync() error  { return nil }
+func (s *memSink) Close() error { return nil }
+
+func Test_accessWriter(t *testing.T) {
+    cases := []struct {
+        name   string
+        serve  func(w http.ResponseWriter)
+        status int
+        bytes  int64
+    }{
+        {"no response", func(w http.ResponseWriter) {}, 0, 0},
+        {"write", func(w http.ResponseWriter) {
+            w.Write([]byte("hello"))
+            w.Write([]byte(" world"))
+        }, http.StatusOK, 11},
+        {"header", func(w http.ResponseWriter) {
+            w.WriteHeader(http.StatusNotFound)
+            w.Write([]byte("missing"))
+        }, http.StatusNotFound, 7},
+        {"second header", func(w http.ResponseWriter) {
+            w.WriteHeader(http.StatusAccepted)
+            w.WriteHeader(http.StatusInternalServerError)
+        }, http.StatusAccepted, 0},
+    }
+    for _, c := range cases {
+        w := &accessWriter{ResponseWriter: httptest.NewRecorder()}
+        c.serve(w)
+        if w.status != c.status || w.bytes != c.bytes {
+            t.Errorf("%s: expect %d %d, got %d %d", c.name, c.status, c.bytes, w.status, w.bytes)
+        }
+    }
+}
+
+//serveAccess serves a request through the access log of format and
+//returns the line written.
+func serveAccess(t *testing.T, format int, serve func(w http.ResponseWriter, r *http.Request)) string {
+    mem := &memSink{}
+    l, err := NewAccessLogger(&log.LogConfig{Sink: mem, Redact: []*log.RedactRule{{Key: "token"}}}, format)
+    if err != nil {
+        t.Fatal(err)
+    }
+    SetAccessLog(l, format)
+    defer SetAccessLog(nil, 0)
+    r := httptest.NewRequest("POST", "/api/user?token=abc&n=1", strings.NewReader("hello"))
+    r.Header.Set("User-Agent", "test-agent")
+    ar, w := startAccess(httptest.NewRecorder(), r)
+    serve(w, r)
+    ar.done(l.Logger(log.LogHeader{LogId: "access"}))
+    l.Shutdown(context.Background())
+    if len(mem.lines) != 1 {
+        t.Fatalf("expect one access line, got %v", mem.lines)
+    }
+    return strings.TrimSuffix(mem.lines[0], "\n")
+}
+
+//reads the body and writes 2 bytes without a status
+func serveOk(w http.ResponseWriter, r *http.Request) {
+    buf := make([]byte, 16)
+    r.Body.Read(buf)
+    w.Write([]byte("ok"))
+}
+
+func Test_accessLine(t *testing.T) {
+    cases := []struct {
+        format int
+        serve  func(w http.ResponseWriter, r *http.Request)
+        want   string
+    }{
+        {AccessCommon, serveOk,
+            `^192\.0\.2\.1 - - \[[^]]+\] "POST /api/user\?token=\*\*\*&n=1 HTTP/1\.1" 200 2 in=5 rt=\d+\.\d{3} logid=access$`},
+        {AccessCombined, serveOk,
+            `^192\.0\.2\.1 - - \[[^]]+\] "POST /api/user\?token=\*\*\*&n=1 HTTP/1\.1" 200 2 "-" "test-agent" in=5 rt=\d+\.\d{3} logid=access$`},
+        {AccessCommon, func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusNoContent)
+        }, `" 204 - in=5 `},
+    }
+    for _, c := range cases {
+        if line := serveAccess(t, c.format, c.serve); !regexp.MustCompile(c.want).MatchString(line) {
+            t.Errorf("format %d: %s does not match %s", c.format, line, c.want)
+        }
+    }
+}
+
+func Test_accessJson(t *testing.T) {
+    line := serveAccess(t, AccessJson, serveOk)
+    var m map[string]interface{}
+    if err := json.Unmarshal([]byte(line), &m); err != nil {
+        t.Fatalf("%s: %s", line, err.Error())
+    }
+    want := map[string]interface{}{
+        "msg":        "access",
+        "logid":      "access",
+        "caller_ip":  "192.0.2.1",
+        "method":     "POST",
+        "path":       "/api/user",
+        "status":     float64(200),
+        "bytes_in":   float64(5),
+        "bytes_out":  float64(2),
+        "user_agent": "test-agent",
+    }
+    for k, v := range want {
+        if m[k] != v {
+            t.Errorf("expect %s %v, got %v in %s", k, v, m[k], line)
+        }
+    }
+    if _, ok := m["latency_ms"].(float64); !ok {
+        t.Errorf("latency_ms missing in %s", line)
+    }
+}
@@ -42,7 +42,15 @@ var schema = validate.NewProperty(validate.TypeObject).Properties(
 func main() {
     fmt.Println("begin...")
     //初始化日志
-    log.Initialize_Base_Logger("./", "test_server.json", 1, log.DEBUG)
+    if err := log.Initialize_Base_Logger("./", "test_server.json", 1, log.DEBUG); err != nil {
+        fmt.Println("init logger failed, logging to stderr:", err)
+    }
+    //访问日志
+    al, err := rest.NewAccessLogger(&log.LogConfig{Path: "./", Name: "access.log"}, rest.AccessCombined)
+    if err != nil {
+        fmt.Println("init access logger failed, logging to stderr:", err)
+    }
+    rest.SetAccessLog(al, rest.AccessCombined)
     //创建映射
     rest.MakeRoute("/test/hello", &HelloWorldHandler{})
     ch := make(chan error)
@@ -52,6 +60,6 @@ func main() {
             ch <- err
         }
     }()
-    err := <-ch
+    err = <-ch
     fmt.Println("order server shutting down,error:", err)
 }
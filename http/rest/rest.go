@@ -19,7 +19,9 @@ const (
 
 type Rest interface {
     Logger() log.Logger //兼容以前的函数
+    Debug(format string, v ...interface{})
     Info(format string, v ...interface{})
+    Warn(format string, v ...interface{})
     Error(format string, v ...interface{})
     Say(format string, v ...interface{})
     SayJson(v interface{})
@@ -32,10 +34,14 @@ type Rest interface {
 
 type httpJsonRest struct {
     l    log.Logger
+    //l reporting the caller of the httpJsonRest method
+    sl   log.Logger
     r    *http.Request
     w    http.ResponseWriter
     data []byte
     j    *simplejson.Json
+    //max logged body size of the route, see BodyLogLimit
+    maxBody int
 }
 
 func (r *httpJsonRest) HttpRequest() *http.Request {
@@ -52,23 +58,31 @@ func (r *httpJsonRest) Logger() log.Logger {
 func (r *httpJsonRest) JsonInput() *simplejson.Json {
     return r.j
 }
+func (r *httpJsonRest) Debug(format string, v ...interface{}) {
+    r.sl.Debug(format, v...)
+}
+
+func (r *httpJsonRest) Warn(format string, v ...interface{}) {
+    r.sl.Warn(format, v...)
+}
+
 func (r *httpJsonRest) Info(format string, v ...interface{}) {
-    r.l.Info(format, v...)
+    r.sl.Info(format, v...)
 }
 
 func (r *httpJsonRest) Error(format string, v ...interface{}) {
-    r.l.Error(format, v...)
+    r.sl.Error(format, v...)
 }
 
 func (r *httpJsonRest) Say(format string, v ...interface{}) {
-    fmt.Fprintf(r.w, format, v)
-    r.Info("response is:"+format, v)
+    fmt.Fprintf(r.w, format, v...)
+    r.sl.Info("response is:"+format, v...)
 }
 
 func (r *httpJsonRest) SayJson(v interface{}) {
     data, _ := json.JSONMarshal(v, true)
     fmt.Fprintf(r.w, "%s", data)
-    r.Info("response is: %s", data)
+    r.sl.Info("response is: %s", log.Body(r.l, data, "application/json", r.maxBody))
 }
 
 func (r *httpJsonRest) SayError(code int, msg string) {
@@ -77,7 +91,7 @@ func (r *httpJsonRest) SayError(code int, msg string) {
     v["msg"] = msg
     data, _ := json.JSONMarshal(v, true)
     fmt.Fprintf(r.w, "%s", data)
-    r.Info("response is: %s", data)
+    r.sl.Info("response is: %s", log.Body(r.l, data, "application/json", r.maxBody))
 }
 
 func (r *httpJsonRest) SayToastError(code int, msg string) {
@@ -86,12 +100,12 @@ func (r *httpJsonRest) SayToastError(code int, msg string) {
     v["user_msg"] = msg
     data, _ := json.JSONMarshal(v, true)
     fmt.Fprintf(r.w, "%s", data)
-    r.Info("response is: %s", data)
+    r.sl.Info("response is: %s", log.Body(r.l, data, "application/json", r.maxBody))
 }
 
 func (r *httpJsonRest) decodeJson() error {
     if j, err := simplejson.NewJson(r.data); err != nil {
-        r.Error("create json failed:%s", err.Error())
+        r.l.Error("create json failed:%s", err.Error())
         return err
     } else {
         r.j = j
@@ -101,19 +115,19 @@ func (r *httpJsonRest) decodeJson() error {
 
 func (r *httpJsonRest) loadParams() error {
     if data, err := ioutil.ReadAll(r.r.Body); err != nil {
-        r.Error("Read request body failed:%s", err.Error())
+        r.l.Error("Read request body failed:%s", err.Error())
         r.SayError(http.StatusNotAcceptable, "Read request body failed.")
         return err
     } else {
         r.data = data
-        r.l.Info("%s", data)
+        r.l.Info("%s", log.Body(r.l, data, r.r.Header.Get("Content-Type"), r.maxBody))
         return nil
     }
 }
 
 func (r *httpJsonRest) auth([]byte) error {
     if j, err := simplejson.NewJson(r.data); err != nil {
-        r.Error("create json failed:%s", err.Error())
+        r.l.Error("create json failed:%s", err.Error())
         r.SayError(http.StatusNotAcceptable, "Unmarshal request data failed.")
         return err
     } else {
@@ -125,7 +139,10 @@ func (r *httpJsonRest) auth([]byte) error {
 func (r *httpJsonRest) authSchemaByte(schema *validate.Property) error {
 
     if err := schema.ValidateString(string(r.data)); err != nil {
-        return fmt.Errorf("validate failed: %s , body is %s", err.Error(), r.data)
+        //the body goes through log.Body like every logged body, so that
+        //it is truncated, skipped or redacted
+        return fmt.Errorf("validate failed: %s , body is %s", err.Error(),
+            log.Body(r.l, r.data, r.r.Header.Get("Content-Type"), r.maxBody))
     }
     return nil
 }
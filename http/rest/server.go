@@ -16,6 +16,12 @@ type Validatable interface {
     GetValidateSchema() *validate.Property
 }
 
+//BodyLogLimit overrides log.LogConfig.MaxBodySize for a route,
+//a negative size logs whole bodies.
+type BodyLogLimit interface {
+    MaxLoggedBodySize() int
+}
+
 type httpHandler struct {
     f func(w http.ResponseWriter, r *http.Request)
 }
@@ -31,14 +37,35 @@ const (
 
 //定义http请求的基本流程
 func getHttpHandler(mode int, h RestHandler, schema *validate.Property) http.Handler {
+    maxBody := 0
+    if b, ok := h.(BodyLogLimit); ok {
+        maxBody = b.MaxLoggedBodySize()
+    }
     return &httpHandler{
         f: func(w http.ResponseWriter, r *http.Request) {
+            ar, w := startAccess(w, r)
             l := log.GetHttpLogger(r)
+            //after the recover below, so that its response is counted
+            defer ar.done(l)
+            //log.FromContext(r.Context()) is l in the handler and below
+            r = r.WithContext(log.NewContext(r.Context(), l))
             rest := &httpJsonRest{
-                l:  l,
-                w:  w,
-                r:  r,
+                l:       l,
+                sl:      log.AddCallerSkip(l, 1),
+                w:       w,
+                r:       r,
+                maxBody: maxBody,
             }
+            defer func() {
+                if err := recover(); err != nil {
+                    //net/http aborts the response for it
+                    if err == http.ErrAbortHandler {
+                        panic(err)
+                    }
+                    log.Recovered(l, err)
+                    rest.SayError(http.StatusInternalServerError, "internal error.")
+                }
+            }()
             switch mode {
             case modeJson:
                 if err := rest.loadParams(); err != nil {
@@ -84,3 +111,11 @@ func MakeRoute(path string, h RestHandler) {
 func MakeRouteForm(path string, h RestHandler) {
     http.Handle(path, getHttpHandler(modeForm, h, nil))
 }
+
+//admin endpoint to change log levels at runtime, see log.LevelHandler.
+//It has no authentication and http.DefaultServeMux usually serves the
+//public listener, so only use it when that listener is admin only.
+//Otherwise serve log.LevelHandler on an admin listener of its own.
+func MakeLevelRoute(path string) {
+    http.Handle(path, log.LevelHandler())
+}
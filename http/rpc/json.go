@@ -26,6 +26,7 @@ var restClient = &http.Client{
 }
 
 func BasicHttpGet(rawurl string, l log.Logger) ([]byte, error) {
+    l = log.AddCallerSkip(l, 1)
     u, err := url.Parse(rawurl)
     tmp := u.Query()
     u.RawQuery = tmp.Encode()
@@ -43,29 +44,30 @@ func BasicHttpGet(rawurl string, l log.Logger) ([]byte, error) {
         return nil, err
     }
     if response.StatusCode != http.StatusOK {
-        l.Error("Post [%s] failed: http code %s", rawurl, responseData)
+        l.Error("Post [%s] failed: http code %s", rawurl, log.Body(l, responseData, response.Header.Get("Content-Type"), 0))
         return nil, fmt.Errorf("http status is not ok.")
     }
     t2 := time.Now()
     sub := t2.Sub(t1).Nanoseconds() / 1000000
-    l.Info("[%s] response is :%s time cost is %v ms", rawurl, responseData, sub)
+    l.Info("[%s] response is :%s time cost is %v ms", rawurl, log.Body(l, responseData, response.Header.Get("Content-Type"), 0), sub)
     return responseData, nil
 }
 
 func TextHttpPost(url, text string, l log.Logger) ([]byte, error) {
+    l = log.AddCallerSkip(l, 1)
     data := []byte(text)
     response, err := http.Post(url, "application/json", bytes.NewBuffer(data))
     if err != nil {
         l.Error("Post [%s] failed:%s", url, err.Error())
         return nil, err
     }
-    l.Info("%s request is %s", url, data)
+    l.Info("%s request is %s", url, log.Body(l, data, "application/json", 0))
     responseData, err := ioutil.ReadAll(response.Body)
     if err != nil {
         l.Error("read form [%s] response failed:%s", url, err.Error())
         return nil, err
     }
-    l.Info("[%s] response is :%s", url, responseData)
+    l.Info("[%s] response is :%s", url, log.Body(l, responseData, response.Header.Get("Content-Type"), 0))
     return responseData, nil
 
 }
@@ -116,6 +118,11 @@ func newHttpRequest(method string, u *url.URL, body io.Reader) *http.Request {
 }
 
 func JsonHttpPost(rawurl string, m interface{}, l log.Logger) ([]byte, error) {
+    return jsonHttpPost(rawurl, m, log.AddCallerSkip(l, 2))
+}
+
+//l has to skip the exported caller as well
+func jsonHttpPost(rawurl string, m interface{}, l log.Logger) ([]byte, error) {
     data, err := json.Marshal(m)
     if err != nil {
         l.Error("marshal [%s] request failed:%s", rawurl, err.Error())
@@ -126,7 +133,7 @@ func JsonHttpPost(rawurl string, m interface{}, l log.Logger) ([]byte, error) {
     tmp.Set("logid", l.Logid())
     u.RawQuery = tmp.Encode()
     req := newHttpRequest("POST", u, bytes.NewBuffer(data))
-    l.Info("[%v] %s", u, data)
+    l.Info("[%v] %s", u, log.Body(l, data, "application/json", 0))
     t1 := time.Now()
     response, err := restClient.Do(req)
     if err != nil {
@@ -139,18 +146,18 @@ func JsonHttpPost(rawurl string, m interface{}, l log.Logger) ([]byte, error) {
         return nil, err
     }
     if response.StatusCode != http.StatusOK {
-        l.Error("Post [%s] failed: http code %s", rawurl, responseData)
+        l.Error("Post [%s] failed: http code %s", rawurl, log.Body(l, responseData, response.Header.Get("Content-Type"), 0))
         return nil, fmt.Errorf("http status is not ok.")
     }
     t2 := time.Now()
     sub := t2.Sub(t1).Nanoseconds() / 1000000
-    l.Info("[%s] response is :%s time cost is %v ms", rawurl, responseData, sub)
+    l.Info("[%s] response is :%s time cost is %v ms", rawurl, log.Body(l, responseData, response.Header.Get("Content-Type"), 0), sub)
     return responseData, nil
 }
 
 //return simplejson object
 func SimpleJsonHttpPost(url string, request interface{}, l log.Logger) (*simplejson.Json, error) {
-    data, err := JsonHttpPost(url, request, l)
+    data, err := jsonHttpPost(url, request, log.AddCallerSkip(l, 2))
     if err != nil {
         return nil, err
     }
@@ -163,7 +170,8 @@ func SimpleJsonHttpPost(url string, request interface{}, l log.Logger) (*simplej
 
 //validate response.
 func JsonPostValidate(url string, request interface{}, p *validate.Property, l log.Logger) (*simplejson.Json, error) {
-    data, err := JsonHttpPost(url, request, l)
+    l = log.AddCallerSkip(l, 1)
+    data, err := jsonHttpPost(url, request, log.AddCallerSkip(l, 1))
     if err != nil {
         return nil, err
     }
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+/*
+*MOD_BINARY的记录格式,整数均为大端或varint:
+*uint32 记录长度 | byte 版本 | varint unix纳秒 | byte level
+*| 字符串 logid product module caller_ip host_ip tag | json msg | json trace
+*| uvarint fields个数 {字符串 key | json value}
+*字符串和json均以uvarint长度开头
+ */
+const binaryVersion = 1
+
+// MaxBinaryRecord bounds the records accepted by BinaryDecoder.
+const MaxBinaryRecord = 64 << 20
+
+type binaryEncoder struct{}
+
+func (e *binaryEncoder) Encode(o *LogObject) ([]byte, error) {
+	b := make([]byte, 4, 256)
+	b = append(b, binaryVersion)
+	ns := o.Timestamp * int64(time.Second)
+	if !o.Time.IsZero() {
+		ns = o.Time.UnixNano()
+	}
+	b = binary.AppendVarint(b, ns)
+	level, err := ParseLevel(o.Level)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, byte(level))
+	for _, s := range []string{o.Logid, o.Product, o.Module, o.Caller_ip, o.Host_ip, o.Tag} {
+		b = appendBytes(b, []byte(s))
+	}
+	for _, v := range []interface{}{o.Msg, o.Trace} {
+		if b, err = appendJson(b, v); err != nil {
+			return nil, err
+		}
+	}
+	b = binary.AppendUvarint(b, uint64(len(o.Fields)))
+	for _, f := range o.Fields {
+		b = appendBytes(b, []byte(f.Key))
+		if b, err = appendJson(b, f.Value); err != nil {
+			return nil, err
+		}
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	return b, nil
+}
+
+func appendBytes(b, s []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendJson(b []byte, v interface{}) ([]byte, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, d), nil
+}
+
+// BinaryDecoder reads the records written by a MOD_BINARY sink.
+type BinaryDecoder struct {
+	r *bufio.Reader
+}
+
+func NewBinaryDecoder(r io.Reader) *BinaryDecoder {
+	return &BinaryDecoder{r: bufio.NewReader(r)}
+}
+
+var errBadRecord = errors.New("malformed binary log record")
+
+// Decode returns the next record, io.EOF at the end of the stream.
+func (d *BinaryDecoder) Decode() (*LogObject, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(d.r, head[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(head[:])
+	if n > MaxBinaryRecord {
+		return nil, fmt.Errorf("binary log record of %d bytes is too large", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(d.r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return decodeBinary(body)
+}
+
+// binaryReader consumes a record body, the first error sticks.
+type binaryReader struct {
+	b   []byte
+	err error
+}
+
+func (r *binaryReader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	n, k := binary.Uvarint(r.b)
+	if k <= 0 || uint64(len(r.b)-k) < n {
+		r.err = errBadRecord
+		return nil
+	}
+	s := r.b[k : k+int(n)]
+	r.b = r.b[k+int(n):]
+	return s
+}
+
+func (r *binaryReader) json(v interface{}) {
+	d := r.bytes()
+	if r.err == nil {
+		r.err = json.Unmarshal(d, v)
+	}
+}
+
+func decodeBinary(body []byte) (*LogObject, error) {
+	if len(body) < 1 || body[0] != binaryVersion {
+		return nil, errBadRecord
+	}
+	r := &binaryReader{b: body[1:]}
+	o := &LogObject{}
+	ts, k := binary.Varint(r.b)
+	if k <= 0 || len(r.b) <= k {
+		return nil, errBadRecord
+	}
+	o.Time = time.Unix(0, ts)
+	o.Timestamp = o.Time.Unix()
+	o.Level = levelStr[int(r.b[k])]
+	r.b = r.b[k+1:]
+	for _, s := range []*string{&o.Logid, &o.Product, &o.Module, &o.Caller_ip, &o.Host_ip, &o.Tag} {
+		*s = string(r.bytes())
+	}
+	r.json(&o.Msg)
+	r.json(&o.Trace)
+	if r.err != nil {
+		return nil, r.err
+	}
+	n, k := binary.Uvarint(r.b)
+	if k <= 0 || n > uint64(len(r.b)) {
+		return nil, errBadRecord
+	}
+	r.b = r.b[k:]
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		f := Field{Key: string(r.bytes())}
+		r.json(&f.Value)
+		o.Fields = append(o.Fields, f)
+	}
+	return o, r.err
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// DEFAULT_SKIP_CONTENT_TYPES are not logged when LogConfig.SkipContentTypes is nil.
+var DEFAULT_SKIP_CONTENT_TYPES = []string{
+	"image/",
+	"audio/",
+	"video/",
+	"multipart/",
+	"application/octet-stream",
+	"application/zip",
+	"application/gzip",
+	"application/pdf",
+	"application/x-protobuf",
+}
+
+// Body prepares a request or response body for logging with the
+// rules of l: binary content types are replaced by a placeholder, the
+// rest is redacted and cut to max bytes. max 0 uses
+// LogConfig.MaxBodySize of l and a negative max keeps the whole body.
+func Body(l Logger, data []byte, contentType string, max int) string {
+	if b, ok := l.(interface {
+		Body(data []byte, contentType string, max int) string
+	}); ok {
+		return b.Body(data, contentType, max)
+	}
+	return truncateBody(data, max)
+}
+
+func (l *BaseLogger) Body(data []byte, contentType string, max int) string {
+	if l.skipContentType(contentType) {
+		return fmt.Sprintf("[%s body, %d bytes]", contentType, len(data))
+	}
+	if max == 0 {
+		max = l.conf.MaxBodySize
+	}
+	return truncateBody(l.Redact(data), max)
+}
+
+func (l *BaseLogger) skipContentType(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = t
+	}
+	skip := l.conf.SkipContentTypes
+	if skip == nil {
+		skip = DEFAULT_SKIP_CONTENT_TYPES
+	}
+	for _, s := range skip {
+		if strings.HasPrefix(contentType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateBody cuts data to max bytes on a utf8 boundary and records
+// the original length.
+func truncateBody(data []byte, max int) string {
+	if max <= 0 || len(data) <= max {
+		return string(data)
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s...[truncated, %d bytes]", data[:n], len(data))
+}
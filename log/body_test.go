@@ -0,0 +1,27 @@
+package log
+
+import (
+	"testing"
+)
+
+func Test_Body(t *testing.T) {
+	l := newLogger(&LogConfig{Sink: &memSink{}, MaxBodySize: 8, Redact: []*RedactRule{{Key: "pwd"}}})
+	hl := l.Logger(LogHeader{})
+	cases := []struct {
+		data        string
+		contentType string
+		max         int
+		want        string
+	}{
+		{`{"pwd":"12345678"}`, "application/json", -1, `{"pwd":"********"}`},
+		{`0123456789`, "text/plain", 0, `01234567...[truncated, 10 bytes]`},
+		{`0123456789`, "text/plain", 4, `0123...[truncated, 10 bytes]`},
+		{`中文内容`, "text/plain", 4, `中...[truncated, 12 bytes]`},
+		{"\x89PNG", "image/png; q=1", 0, `[image/png; q=1 body, 4 bytes]`},
+	}
+	for _, c := range cases {
+		if got := Body(hl, []byte(c.data), c.contentType, c.max); got != c.want {
+			t.Errorf("Body(%q) expect %q, got %q", c.data, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// wrapped is a library function logging on behalf of its caller.
+func wrapped(l Logger) {
+	AddCallerSkip(l, 1).Info("wrapped")
+}
+
+func here() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("[%s:%d]", shortFileName(file), line+1)
+}
+
+func Test_caller(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem})
+	old := Default()
+	SetDefault(l)
+	defer SetDefault(old)
+
+	h := LogHeader{LogId: "caller"}
+	var want []string
+	want = append(want, here())
+	Info(h, "package")
+	want = append(want, here())
+	l.Error(h, "method")
+	want = append(want, here())
+	l.Tag(h, "tag", "msg")
+	want = append(want, here())
+	l.Logger(h).Warn("bound")
+	want = append(want, here())
+	newTestHttpLogger().Errorw("default http logger")
+	want = append(want, here())
+	wrapped(l.Logger(h).With("k", "v"))
+	l.Shutdown(context.Background())
+
+	if len(mem.lines) != len(want) {
+		t.Fatalf("expect %d lines, got %v", len(want), mem.lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(mem.lines[i], w) {
+			t.Errorf("line %d: expect %s in %s", i, w, mem.lines[i])
+		}
+	}
+}
+
+func newTestHttpLogger() Logger {
+	return &httpLogger{h: LogHeader{LogId: "http"}}
+}
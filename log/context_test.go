@@ -0,0 +1,31 @@
+package log
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_context(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem})
+	old := Default()
+	SetDefault(l)
+	defer SetDefault(old)
+
+	r := httptest.NewRequest("GET", "/ctx?logid=abc&cid=1", nil)
+	ctx := NewContext(context.Background(), GetHttpLogger(r))
+	FromContext(ctx).Info("deep")
+	FromContext(context.Background()).Info("no logger")
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 2 {
+		t.Fatalf("expect 2 lines, got %v", mem.lines)
+	}
+	if !strings.Contains(mem.lines[0], "[context_test.go:19][INFO] [abc###1][][/ctx] MSG:deep") {
+		t.Errorf("unexpected line %s", mem.lines[0])
+	}
+	if !strings.Contains(mem.lines[1], "[context_test.go:20][INFO] [][][] MSG:no logger") {
+		t.Errorf("unexpected line %s", mem.lines[1])
+	}
+}
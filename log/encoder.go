@@ -0,0 +1,173 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoder turns a LogObject into the bytes written to a sink, the
+// result has to be self delimiting, e.g. terminated by a newline.
+type Encoder interface {
+	Encode(o *LogObject) ([]byte, error)
+}
+
+type encoderKey struct {
+	mode int
+	ts   int
+}
+
+// encoders of the LogConfig.Mode values based on LogObject, MOD_NORMAL
+// uses the text layout instead. They are shared by the sinks so that
+// an entry is encoded once per format.
+var encoders = map[encoderKey]Encoder{}
+
+func init() {
+	for ts := TS_SECOND; ts <= TS_RFC3339NANO; ts++ {
+		encoders[encoderKey{MOD_JSON, ts}] = &jsonEncoder{ts: ts}
+		encoders[encoderKey{MOD_LOGFMT, ts}] = &logfmtEncoder{ts: ts}
+	}
+	encoders[encoderKey{MOD_BINARY, TS_SECOND}] = &binaryEncoder{}
+}
+
+// NewEncoder returns the builtin Encoder of mode with TS_SECOND
+// timestamps, nil for MOD_NORMAL.
+func NewEncoder(mode int) Encoder {
+	return NewTimestampEncoder(mode, TS_SECOND)
+}
+
+// NewTimestampEncoder is NewEncoder with timestamps in format ts, one
+// of the TimestampFormat values. MOD_BINARY always keeps nanoseconds.
+func NewTimestampEncoder(mode, ts int) Encoder {
+	if mode == MOD_BINARY {
+		ts = TS_SECOND
+	}
+	return encoders[encoderKey{mode, ts}]
+}
+
+// newEncoder returns the Encoder of c, nil means the text layout.
+func newEncoder(c *LogConfig) Encoder {
+	if c.Encoder != nil {
+		return c.Encoder
+	}
+	if c.Mode == MOD_BINARY {
+		//binary records always keep nanoseconds
+		return encoders[encoderKey{MOD_BINARY, TS_SECOND}]
+	}
+	return encoders[encoderKey{c.Mode, c.TimestampFormat}]
+}
+
+// timestamp renders the time of o in format ts, objects without Time
+// such as decoded ones fall back to Timestamp.
+func timestamp(o *LogObject, ts int) interface{} {
+	if o.Time.IsZero() {
+		return o.Timestamp
+	}
+	switch ts {
+	case TS_MILLI:
+		return o.Time.UnixMilli()
+	case TS_NANO:
+		return o.Time.UnixNano()
+	case TS_RFC3339NANO:
+		return o.Time.Format(time.RFC3339Nano)
+	default:
+		return o.Time.Unix()
+	}
+}
+
+type jsonEncoder struct {
+	ts int
+}
+
+// jsonLogObject overrides the timestamp of the embedded LogObject.
+type jsonLogObject struct {
+	Timestamp interface{} `json:"timestamp"`
+	*plainLogObject
+}
+
+func (e *jsonEncoder) Encode(o *LogObject) ([]byte, error) {
+	d, err := marshalObject(o, &jsonLogObject{
+		Timestamp:      timestamp(o, e.ts),
+		plainLogObject: (*plainLogObject)(o),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(d, '\n'), nil
+}
+
+// logfmtEncoder writes one key=value line per entry, empty header
+// values are left out.
+type logfmtEncoder struct {
+	ts int
+}
+
+func (e *logfmtEncoder) Encode(o *LogObject) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("ts=")
+	fmt.Fprint(&b, timestamp(o, e.ts))
+	writeLogfmt(&b, "level", o.Level, false)
+	writeLogfmt(&b, "logid", o.Logid, true)
+	writeLogfmt(&b, "product", o.Product, true)
+	writeLogfmt(&b, "module", o.Module, true)
+	writeLogfmt(&b, "caller_ip", o.Caller_ip, true)
+	writeLogfmt(&b, "host_ip", o.Host_ip, true)
+	writeLogfmt(&b, "tag", o.Tag, true)
+	if file, ok := o.Trace["File"]; ok {
+		writeLogfmt(&b, "caller", fmt.Sprintf("%v:%v", file, o.Trace["Line"]), false)
+	}
+	keys := make([]string, 0, len(o.Trace))
+	for k := range o.Trace {
+		if k != "File" && k != "Line" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeLogfmt(&b, "trace."+k, logfmtValue(o.Trace[k]), false)
+	}
+	writeLogfmt(&b, "msg", logfmtValue(o.Msg), false)
+	for _, f := range o.Fields {
+		writeLogfmt(&b, f.Key, logfmtValue(f.Value), false)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// logfmtValue prints strings and numbers as is and the rest as json.
+func logfmtValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(x)
+	}
+	d, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return string(d)
+}
+
+func writeLogfmt(b *bytes.Buffer, key, value string, omitEmpty bool) {
+	if value == "" && omitEmpty {
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(key)
+	b.WriteByte('=')
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		value = strconv.Quote(value)
+	}
+	b.WriteString(value)
+}
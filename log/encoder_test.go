@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testObject() *LogObject {
+	return &LogObject{
+		Timestamp: 1577934245,
+		Level:     Warn_str,
+		Logid:     "id",
+		Module:    "/a",
+		Msg:       "hello world",
+		Trace:     map[string]interface{}{"File": "main.go", "Line": float64(7)},
+		Tag:       "t",
+		Fields:    []Field{String("user", "bob"), Any("n", float64(1))},
+	}
+}
+
+func Test_logfmtEncoder(t *testing.T) {
+	d, err := NewEncoder(MOD_LOGFMT).Encode(testObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ts=1577934245 level=WARN logid=id module=/a tag=t caller=main.go:7 msg="hello world" user=bob n=1` + "\n"
+	if string(d) != want {
+		t.Errorf("expect %q, got %q", want, d)
+	}
+}
+
+func Test_binaryEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	o := testObject()
+	o.Time = time.Unix(o.Timestamp, 123456789)
+	for i := 0; i < 2; i++ {
+		d, err := NewEncoder(MOD_BINARY).Encode(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(d)
+	}
+	dec := NewBinaryDecoder(&buf)
+	for i := 0; i < 2; i++ {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Time.Equal(o.Time) {
+			t.Errorf("expect time %v, got %v", o.Time, got.Time)
+		}
+		got.Time = o.Time
+		if !reflect.DeepEqual(got, o) {
+			t.Errorf("expect %+v, got %+v", o, got)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expect EOF, got %v", err)
+	}
+}
+
+func Test_timestampFormat(t *testing.T) {
+	o := testObject()
+	o.Time = time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	for ts, want := range map[int]string{
+		TS_SECOND:      `{"timestamp":1577934245,`,
+		TS_MILLI:       `{"timestamp":1577934245123,`,
+		TS_NANO:        `{"timestamp":1577934245123456789,`,
+		TS_RFC3339NANO: `{"timestamp":"2020-01-02T03:04:05.123456789Z",`,
+	} {
+		d, err := newEncoder(&LogConfig{Mode: MOD_JSON, TimestampFormat: ts}).Encode(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(d), want) || strings.Count(string(d), "timestamp") != 1 {
+			t.Errorf("expect prefix %s, got %s", want, d)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"log"
+)
+
+// Errors returns how many times logging of the default logger failed.
+func Errors() uint64 {
+	return Default().Errors()
+}
+
+// Errors returns how many times the logger failed to open, rotate or
+// write its files, encode an entry or write to a sink. An increasing
+// count means entries are being lost or written to stderr instead.
+func (l *BaseLogger) Errors() uint64 {
+	return l.errCount.Load()
+}
+
+// reportError counts err and passes it to LogConfig.OnError, or prints
+// it to stderr.
+func (l *BaseLogger) reportError(err error) {
+	l.errCount.Add(1)
+	if l.conf.OnError != nil {
+		l.conf.OnError(err)
+		return
+	}
+	log.Printf("%v", err)
+}
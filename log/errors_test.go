@@ -0,0 +1,130 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_fileErrors(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var reported []error
+	c := &LogConfig{
+		//a directory that does not exist yet
+		Path: filepath.Join(dir, "logs"),
+		Name: "errors.log",
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	}
+	l, err := NewBaseLogger(c)
+	if err == nil || !strings.Contains(err.Error(), "errors.log") {
+		t.Fatalf("expect an open error, got %v", err)
+	}
+	if l.Errors() != 1 {
+		t.Errorf("expect 1 error, got %d", l.Errors())
+	}
+	//written to stderr instead of panicking
+	l.Info(LogHeader{}, "to stderr")
+	l.Sync()
+
+	os.Mkdir(c.Path, 0755)
+	l.fileSink.checked = time.Time{}
+	l.Info(LogHeader{}, "to file")
+	l.Shutdown(context.Background())
+	data, _ := os.ReadFile(filepath.Join(c.Path, "errors.log"))
+	if !strings.Contains(string(data), "to file") || strings.Contains(string(data), "to stderr") {
+		t.Errorf("unexpected file content %s", data)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 {
+		t.Errorf("unexpected reported errors %v", reported)
+	}
+}
+
+func Test_rotateError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	os.Mkdir(dir, 0755)
+	var reported int
+	l := newLogger(&LogConfig{
+		Path:    dir,
+		Name:    "rotate.log",
+		Rotate:  ROTATE_SIZE,
+		MaxSize: 1,
+		OnError: func(err error) { reported++ },
+	})
+	h := LogHeader{}
+	l.Info(h, "first")
+	l.Sync()
+	//neither the rename nor the new file can succeed
+	os.RemoveAll(dir)
+	l.Info(h, "to stderr")
+	l.Sync()
+	if reported != 1 || l.Errors() != 1 {
+		t.Errorf("expect one rotation error, got %d", reported)
+	}
+
+	os.Mkdir(dir, 0755)
+	l.fileSink.checked = time.Time{}
+	l.Info(h, "to file")
+	l.Shutdown(context.Background())
+	data, _ := os.ReadFile(filepath.Join(dir, "rotate.log"))
+	if string(data) == "" || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("unexpected file content %s", data)
+	}
+}
+
+func Test_rotateRenameError(t *testing.T) {
+	for _, rotate := range []int{ROTATE_SIZE, ROTATE_DAILY} {
+		rename = func(string, string) error { return os.ErrPermission }
+		dir := t.TempDir()
+		var reported int
+		l := newLogger(&LogConfig{
+			Path:    dir,
+			Name:    "rename.log",
+			Rotate:  rotate,
+			MaxSize: 1,
+			OnError: func(err error) { reported++ },
+		})
+		yesterday := l.periodStart(time.Now().Add(-24 * time.Hour))
+		l.mu.Lock()
+		l.date = yesterday
+		l.mu.Unlock()
+		h := LogHeader{}
+		for i := 0; i < 10; i++ {
+			l.Info(h, "line %d", i)
+		}
+		l.Sync()
+		//the file is reopened but the rotation is only retried a second later
+		if reported != 1 {
+			t.Errorf("rotate %d: expect one rotation error, got %d", rotate, reported)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, "rename.log"))
+		if n := strings.Count(string(data), "\n"); n != 10 {
+			t.Errorf("rotate %d: expect 10 lines, got %d", rotate, n)
+		}
+		l.mu.Lock()
+		if !l.isNeedRotate() || !l.date.Equal(yesterday) {
+			t.Errorf("rotate %d: failed rotation is not retried", rotate)
+		}
+		//the retry after the interval succeeds
+		rename = os.Rename
+		l.rotateFailed = time.Time{}
+		l.mu.Unlock()
+		l.Info(h, "retried")
+		l.Shutdown(context.Background())
+		backup := filepath.Join(dir, "rename.log."+yesterday.Format(DATEFORMAT))
+		if data, _ := os.ReadFile(backup); strings.Count(string(data), "\n") != 10 {
+			t.Errorf("rotate %d: expect 10 lines in %s, got %q", rotate, backup, data)
+		}
+	}
+	rename = os.Rename
+}
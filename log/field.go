@@ -0,0 +1,169 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a key/value pair attached to a log entry. In MOD_JSON it is
+// written as a top level key of the LogObject, in MOD_NORMAL as key=value.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+const badKey = "!BADKEY"
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Err stores err.Error() under "error", a nil err is stored as null.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// toFields converts alternating key/value pairs into fields, Field
+// values may be mixed in. A value without a string key is stored
+// under "!BADKEY".
+func toFields(kv []interface{}) []Field {
+	fields := make([]Field, 0, len(kv)/2)
+	for i := 0; i < len(kv); {
+		switch k := kv[i].(type) {
+		case Field:
+			fields = append(fields, k)
+			i++
+		case string:
+			if i+1 >= len(kv) {
+				fields = append(fields, Field{Key: badKey, Value: k})
+				i++
+				continue
+			}
+			fields = append(fields, Field{Key: k, Value: kv[i+1]})
+			i += 2
+		default:
+			fields = append(fields, Field{Key: badKey, Value: k})
+			i++
+		}
+	}
+	return fields
+}
+
+// withFields returns a copy of h carrying its own fields plus fields,
+// the parent's slice is never shared with the child. A key given more
+// than once keeps its first position and its last value.
+func withFields(h LogHeader, fields []Field) LogHeader {
+	merged := make([]Field, 0, len(h.Fields)+len(fields))
+	for _, f := range append(h.Fields[:len(h.Fields):len(h.Fields)], fields...) {
+		merged = setField(merged, f)
+	}
+	h.Fields = merged
+	return h
+}
+
+// setField replaces the value of f.Key in fields or appends f, entries
+// carry a handful of fields so a scan is cheaper than a map.
+func setField(fields []Field, f Field) []Field {
+	for i := range fields {
+		if fields[i].Key == f.Key {
+			fields[i].Value = f.Value
+			return fields
+		}
+	}
+	return append(fields, f)
+}
+
+// reservedKeys are the LogObject keys a field cannot overwrite, such
+// fields are written as "fields.<key>" instead.
+var reservedKeys = map[string]bool{
+	"timestamp": true,
+	"level":     true,
+	"logid":     true,
+	"product":   true,
+	"module":    true,
+	"caller_ip": true,
+	"host_ip":   true,
+	"msg":       true,
+	"trace":     true,
+	"tag":       true,
+}
+
+type plainLogObject LogObject
+
+// MarshalJSON writes Fields as top level keys after the fixed ones.
+func (o *LogObject) MarshalJSON() ([]byte, error) {
+	return marshalObject(o, (*plainLogObject)(o))
+}
+
+// marshalObject marshals v, a view of o, and appends the fields of o.
+func marshalObject(o *LogObject, v interface{}) ([]byte, error) {
+	d, err := json.Marshal(v)
+	if err != nil || len(o.Fields) == 0 {
+		return d, err
+	}
+	var buf bytes.Buffer
+	buf.Write(d[:len(d)-1])
+	for _, f := range o.Fields {
+		key := f.Key
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(f.Value)
+		if err != nil {
+			v, _ = json.Marshal(fmt.Sprintf("%+v", f.Value))
+		}
+		buf.WriteByte(',')
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// appendText writes fields as " key=value" pairs for MOD_NORMAL, values
+// containing spaces or quotes are quoted.
+func appendText(b *strings.Builder, fields []Field) {
+	for _, f := range fields {
+		v := fmt.Sprintf("%+v", f.Value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = strconv.Quote(v)
+		}
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+}
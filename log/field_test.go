@@ -0,0 +1,64 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_fields(t *testing.T) {
+	text := &memSink{}
+	obj := &memSink{}
+	l := newLogger(&LogConfig{
+		Sink: text,
+		Sinks: []*LogConfig{
+			{Sink: obj, Mode: MOD_JSON},
+		},
+	})
+	h := withFields(LogHeader{LogId: "fields"}, toFields([]interface{}{"user", 42}))
+	l.Infow(h, "login", "ip", "1.2.3.4", Err(errors.New("bad password")), "level", "x")
+	l.Shutdown(context.Background())
+
+	if !strings.HasSuffix(text.lines[0], `MSG:login user=42 ip=1.2.3.4 error="bad password" level=x`+"\n") {
+		t.Errorf("unexpected text line %q", text.lines[0])
+	}
+	for _, want := range []string{`"user":42`, `"ip":"1.2.3.4"`, `"error":"bad password"`, `"fields.level":"x"`, `"level":"INFO"`} {
+		if !strings.Contains(obj.lines[0], want) {
+			t.Errorf("%s missing in %s", want, obj.lines[0])
+		}
+	}
+}
+
+func Test_withFields(t *testing.T) {
+	parent := withFields(LogHeader{}, []Field{String("a", "1")})
+	child1 := withFields(parent, []Field{String("b", "2")})
+	child2 := withFields(parent, []Field{String("c", "3")})
+	if len(parent.Fields) != 1 || child1.Fields[1].Key != "b" || child2.Fields[1].Key != "c" {
+		t.Errorf("children must not share fields: %v %v %v", parent.Fields, child1.Fields, child2.Fields)
+	}
+}
+
+func Test_duplicateFields(t *testing.T) {
+	text := &memSink{}
+	obj := &memSink{}
+	logfmt := &memSink{}
+	l := newLogger(&LogConfig{
+		Sink: text,
+		Sinks: []*LogConfig{
+			{Sink: obj, Mode: MOD_JSON},
+			{Sink: logfmt, Mode: MOD_LOGFMT},
+		},
+	})
+	l.Logger(LogHeader{}).With("user", 1, "ip", "a").Infow("m", "user", 2, "ip", "b", "ip", "c")
+	l.Shutdown(context.Background())
+	if !strings.HasSuffix(text.lines[0], "MSG:m user=2 ip=c\n") {
+		t.Errorf("unexpected text line %q", text.lines[0])
+	}
+	if !strings.HasSuffix(obj.lines[0], `,"user":2,"ip":"c"}`+"\n") {
+		t.Errorf("unexpected json line %q", obj.lines[0])
+	}
+	if strings.Count(logfmt.lines[0], "user=") != 1 || !strings.Contains(logfmt.lines[0], "user=2") {
+		t.Errorf("unexpected logfmt line %q", logfmt.lines[0])
+	}
+}
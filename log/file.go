@@ -0,0 +1,230 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fileSink writes to a file and rotates it according to its LogConfig.
+type fileSink struct {
+	mu       *sync.RWMutex
+	conf     *LogConfig
+	fileDir  string
+	fileName string
+
+	//start of the period the current file belongs to
+	date time.Time
+	//last check for a moved file, or last try to open a broken one
+	checked time.Time
+	//last failed rotation, retried after reopenCheckInterval
+	rotateFailed time.Time
+
+	logFile *os.File
+	counter *countWriter
+	//errors of file operations, see LogConfig.OnError
+	onError func(err error)
+
+	cleanChan chan struct{}
+}
+
+// countWriter counts the bytes of the current file so that
+// size based rotation needs no stat on the write path.
+type countWriter struct {
+	w    io.Writer
+	size int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.size += int64(n)
+	return n, err
+}
+
+// newFileSink opens the file of c, when it cannot be opened the sink
+// writes to stderr and retries, see reopen. Later errors go to onError.
+func newFileSink(c *LogConfig, onError func(err error)) (*fileSink, error) {
+	f := &fileSink{
+		mu:       new(sync.RWMutex),
+		conf:     c,
+		fileDir:  c.Path,
+		fileName: c.Name,
+		onError:  onError,
+	}
+	err := f.initDailyLogger()
+	return f, err
+}
+
+func (l *fileSink) initDailyLogger() error {
+	l.date = l.periodStart(time.Now())
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.reopen()
+	l.checked = time.Now()
+
+	if l.needCleanup() {
+		l.cleanChan = make(chan struct{}, 1)
+		go l.cleanupLoop(l.cleanChan)
+		l.notifyCleanup()
+	}
+	return err
+}
+
+// resetWriter binds counter to the current logFile, or to stderr while
+// there is none.
+func (l *fileSink) resetWriter() {
+	if l.logFile == nil {
+		l.counter = &countWriter{w: os.Stderr}
+		return
+	}
+	l.counter = &countWriter{w: l.logFile}
+	if fi, err := l.logFile.Stat(); err == nil {
+		l.counter.size = fi.Size()
+	}
+}
+
+// report passes an error of the file operations to the logger.
+func (l *fileSink) report(err error) {
+	if l.onError != nil {
+		l.onError(err)
+		return
+	}
+	log.Printf("%v", err)
+}
+
+// periodStart returns the start of the rotation period t belongs to.
+func (l *fileSink) periodStart(t time.Time) time.Time {
+	switch l.conf.Rotate {
+	case ROTATE_HOURLY:
+		p, _ := time.Parse(HOURFORMAT, t.Format(HOURFORMAT))
+		return p
+	case ROTATE_INTERVAL:
+		return t
+	default:
+		p, _ := time.Parse(DATEFORMAT, t.Format(DATEFORMAT))
+		return p
+	}
+}
+
+func (l *fileSink) backupFormat() string {
+	switch l.conf.Rotate {
+	case ROTATE_HOURLY, ROTATE_INTERVAL:
+		return HOURFORMAT
+	default:
+		return DATEFORMAT
+	}
+}
+
+func (l *fileSink) isNeedRotate() bool {
+	now := time.Now()
+	switch l.conf.Rotate {
+	case ROTATE_EXTERNAL:
+		return false
+	case ROTATE_INTERVAL:
+		if l.conf.RotateInterval <= 0 {
+			return false
+		}
+		return now.Sub(l.date) >= time.Duration(l.conf.RotateInterval)*time.Second
+	case ROTATE_SIZE:
+		if l.conf.MaxSize > 0 && l.counter.size >= l.conf.MaxSize {
+			return true
+		}
+	}
+	return l.periodStart(now).After(l.date)
+}
+
+// rotate renames the current file to a backup and opens a new one. If
+// the rename fails, writing goes on to the current file and the period
+// is kept, so that the rotation is retried.
+func (l *fileSink) rotate() error {
+	logFile := joinFilePath(l.fileDir, l.fileName)
+	originBakName := logFile + "." + l.date.Format(l.backupFormat())
+	logFileBak := originBakName
+	for i := 0; ; i++ {
+		//compressed backups keep their name
+		if isExist(logFileBak) || isExist(logFileBak+compressSuffix) {
+			logFileBak = originBakName + "_" + strconv.Itoa(i)
+		} else {
+			break
+		}
+	}
+	if l.logFile != nil {
+		l.logFile.Close()
+		l.logFile = nil
+	}
+	var err error
+	if rerr := rename(logFile, logFileBak); rerr != nil {
+		err = fmt.Errorf("logger rotate %s: %w", logFile, rerr)
+	} else {
+		l.date = l.periodStart(time.Now())
+		l.notifyCleanup()
+	}
+	return errors.Join(err, l.reopen())
+}
+
+// checkFile is called before every write, so rotation happens on
+// the write path instead of a periodic scan. While the file cannot be
+// opened or renamed, it is retried every reopenCheckInterval; a failed
+// rotation keeps isNeedRotate true until a rename succeeds.
+func (l *fileSink) checkFile() {
+	if l.conf.Rotate == ROTATE_EXTERNAL {
+		l.checkMoved()
+		return
+	}
+	if l.logFile == nil {
+		if time.Since(l.checked) < reopenCheckInterval {
+			return
+		}
+		l.checked = time.Now()
+		if err := l.reopen(); err != nil {
+			l.report(err)
+			return
+		}
+	}
+	if l.isNeedRotate() && time.Since(l.rotateFailed) >= reopenCheckInterval {
+		if err := l.rotate(); err != nil {
+			l.checked = time.Now()
+			l.rotateFailed = l.checked
+			l.report(err)
+		}
+	}
+}
+
+// rename is replaced in tests.
+var rename = os.Rename
+
+func (l *fileSink) Write(level int, p []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checkFile()
+	_, err := l.counter.Write(p)
+	return err
+}
+
+func (l *fileSink) Sync() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.logFile == nil {
+		return nil
+	}
+	return l.logFile.Sync()
+}
+
+func (l *fileSink) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cleanChan != nil {
+		close(l.cleanChan)
+		l.cleanChan = nil
+	}
+	if l.logFile == nil {
+		return nil
+	}
+	return l.logFile.Close()
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Hook is called with the LogObject of every entry of the levels it was
+// added for, e.g. to count errors or push them to an alert queue. Hooks
+// run one at a time on a goroutine of their own, so they neither delay
+// the caller nor the writes. A panicking hook is recovered and reported
+// like the other failures of the logger, see Errors.
+type Hook func(o *LogObject)
+
+type hookEntry struct {
+	levels [FATAL + 1]bool
+	fn     Hook
+}
+
+// AddHook registers h on the default logger.
+func AddHook(h Hook, levels ...int) {
+	Default().AddHook(h, levels...)
+}
+
+// DroppedHooks returns how many hook calls the default logger has
+// dropped because its hooks fell behind.
+func DroppedHooks() uint64 {
+	return Default().DroppedHooks()
+}
+
+// DroppedHooks returns how many hook calls were dropped because the
+// hooks were too slow to keep up, the entries were written anyway.
+func (l *BaseLogger) DroppedHooks() uint64 {
+	return l.hookDropped.Load()
+}
+
+// AddHook registers h for levels, ERROR and above when none are given.
+// Hooks see the entries passing the logger levels only, after
+// redaction.
+func (l *BaseLogger) AddHook(h Hook, levels ...int) {
+	if len(levels) == 0 {
+		levels = []int{ERROR, PANIC, FATAL}
+	}
+	he := &hookEntry{fn: h}
+	for _, level := range levels {
+		if level >= DEBUG && level <= FATAL {
+			he.levels[level] = true
+		}
+	}
+	l.hookMu.Lock()
+	defer l.hookMu.Unlock()
+	var hooks []*hookEntry
+	if cur := l.hooks.Load(); cur != nil {
+		hooks = append(hooks, *cur...)
+	}
+	hooks = append(hooks, he)
+	l.hooks.Store(&hooks)
+}
+
+// hooked reports whether a hook is registered for level.
+func (l *BaseLogger) hooked(level int) bool {
+	if hooks := l.hooks.Load(); hooks != nil {
+		for _, h := range *hooks {
+			if h.levels[level] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fire hands o to the hook goroutine, which is started by the first
+// call. It runs on the writeLog goroutine only. o is dropped and
+// counted by DroppedHooks when the hooks are too slow to keep up.
+func (l *BaseLogger) fire(o *LogObject, level int) {
+	if l.hookChan == nil {
+		l.hookChan = make(chan hookCall, cap(l.logChan))
+		l.hookDone = make(chan struct{})
+		go l.runHooks()
+	}
+	select {
+	case l.hookChan <- hookCall{o, level}:
+	default:
+		l.hookDropped.Add(1)
+	}
+}
+
+type hookCall struct {
+	o     *LogObject
+	level int
+}
+
+func (l *BaseLogger) runHooks() {
+	defer close(l.hookDone)
+	for c := range l.hookChan {
+		for _, h := range *l.hooks.Load() {
+			if h.levels[c.level] {
+				l.callHook(h.fn, c.o)
+			}
+		}
+	}
+}
+
+func (l *BaseLogger) callHook(h Hook, o *LogObject) {
+	defer func() {
+		if err := recover(); err != nil {
+			l.reportError(fmt.Errorf("logger hook panic: %v\n%s", err, debug.Stack()))
+		}
+	}()
+	h(o)
+}
+
+// stopHooks waits for the queued hook calls, it runs on the writeLog
+// goroutine once logChan is drained.
+func (l *BaseLogger) stopHooks() {
+	if l.hookChan != nil {
+		close(l.hookChan)
+		<-l.hookDone
+	}
+}
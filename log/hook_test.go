@@ -0,0 +1,58 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_hooks(t *testing.T) {
+	mem := &memSink{}
+	var reported []error
+	l := newLogger(&LogConfig{Sink: mem, OnError: func(err error) { reported = append(reported, err) }})
+	var got []string
+	l.AddHook(func(o *LogObject) {
+		panic("broken hook")
+	})
+	l.AddHook(func(o *LogObject) {
+		got = append(got, o.Level+" "+o.Msg.(string))
+	})
+	l.AddHook(func(o *LogObject) {
+		got = append(got, "warn hook "+o.Msg.(string))
+	}, WARN)
+	h := LogHeader{LogId: "hooks"}
+	l.Info(h, "no hook")
+	l.Warn(h, "warned")
+	l.Error(h, "failed %d", 1)
+	l.Info(h, "after panic")
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed:%s", err.Error())
+	}
+	if strings.Join(got, "|") != "warn hook warned|ERROR failed 1" {
+		t.Errorf("unexpected hook calls %v", got)
+	}
+	if len(mem.lines) != 4 {
+		t.Errorf("expect 4 lines, got %v", mem.lines)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "broken hook") {
+		t.Errorf("hook panic not reported: %v", reported)
+	}
+}
+
+func Test_droppedHooks(t *testing.T) {
+	release := make(chan struct{})
+	l := newLogger(&LogConfig{Sink: &memSink{}, BufferSize: 1})
+	l.AddHook(func(o *LogObject) { <-release })
+	for i := 0; i < 10; i++ {
+		l.Error(LogHeader{}, "slow hook %d", i)
+	}
+	l.Sync()
+	close(release)
+	l.Shutdown(context.Background())
+	if l.DroppedHooks() == 0 {
+		t.Errorf("expect dropped hook calls")
+	}
+	if n := l.Dropped(); n != 0 {
+		t.Errorf("hook calls counted as dropped entries: %d", n)
+	}
+}
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+*MOD_NORMAL的行格式,可用的占位符:
+*%time %level %logid %reqid %hostid %module %product %callerip %hostip
+*%caller(file:line) %file %line %tag %msg %fields(以" key=value"追加)
+*%stack(每帧一行,以"\n\t"开头,没有堆栈时为空)
+*Pattern中没有%fields或%stack时,它们依次追加在行尾
+ */
+const (
+	DEFAULT_PATTERN     = "%time [%caller][%level] [%logid][%reqid][%module] MSG:%msg%fields"
+	DEFAULT_TIME_FORMAT = "2006/01/02 15:04:05.000000"
+)
+
+// layoutTokens are matched in order, %callerip has to come before %caller.
+var layoutTokens = []string{
+	"time", "level", "logid", "reqid", "hostid", "module", "product",
+	"callerip", "hostip", "caller", "file", "line", "tag", "msg", "fields", "stack",
+}
+
+// layout renders entries as MOD_NORMAL lines.
+type layout struct {
+	parts      []layoutPart
+	timeFormat string
+	loc        *time.Location
+}
+
+// layoutPart is either a literal or a token.
+type layoutPart struct {
+	lit string
+	tok string
+}
+
+var defaultLayout, _ = compileLayout(&LogConfig{})
+
+// newLayout returns the layout of c, sinks without options share
+// defaultLayout so that their lines are formatted once.
+func newLayout(c *LogConfig) (*layout, error) {
+	if c.Pattern == "" && c.TimeFormat == "" && c.TimeZone == "" {
+		return defaultLayout, nil
+	}
+	return compileLayout(c)
+}
+
+// compileLayout compiles the Pattern, TimeFormat and TimeZone of c. An
+// unknown TimeZone is returned as error along with a layout in local
+// time.
+func compileLayout(c *LogConfig) (*layout, error) {
+	pattern := c.Pattern
+	if pattern == "" {
+		pattern = DEFAULT_PATTERN
+	}
+	if !strings.Contains(pattern, "%fields") {
+		pattern += "%fields"
+	}
+	if !strings.Contains(pattern, "%stack") {
+		pattern += "%stack"
+	}
+	l := &layout{
+		timeFormat: c.TimeFormat,
+		loc:        time.Local,
+	}
+	if l.timeFormat == "" {
+		l.timeFormat = DEFAULT_TIME_FORMAT
+	}
+	var err error
+	if c.TimeZone != "" {
+		var loc *time.Location
+		if loc, err = time.LoadLocation(c.TimeZone); err != nil {
+			err = fmt.Errorf("logger time zone %s: %w", c.TimeZone, err)
+		} else {
+			l.loc = loc
+		}
+	}
+	var lit strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '%' {
+			if tok := matchToken(pattern[i+1:]); tok != "" {
+				if lit.Len() > 0 {
+					l.parts = append(l.parts, layoutPart{lit: lit.String()})
+					lit.Reset()
+				}
+				l.parts = append(l.parts, layoutPart{tok: tok})
+				i += len(tok)
+				continue
+			}
+		}
+		lit.WriteByte(pattern[i])
+	}
+	if lit.Len() > 0 {
+		l.parts = append(l.parts, layoutPart{lit: lit.String()})
+	}
+	return l, err
+}
+
+func matchToken(s string) string {
+	for _, tok := range layoutTokens {
+		if strings.HasPrefix(s, tok) {
+			return tok
+		}
+	}
+	return ""
+}
+
+func (l *layout) format(e *entry) []byte {
+	var b strings.Builder
+	h := &e.header
+	for _, p := range l.parts {
+		switch p.tok {
+		case "":
+			b.WriteString(p.lit)
+		case "time":
+			b.WriteString(e.time.In(l.loc).Format(l.timeFormat))
+		case "level":
+			b.WriteString(levelStr[e.level])
+		case "logid":
+			b.WriteString(h.LogId)
+		case "reqid":
+			b.WriteString(h.ReqId)
+		case "hostid":
+			b.WriteString(h.HostId)
+		case "module":
+			b.WriteString(h.Module)
+		case "product":
+			b.WriteString(h.Product)
+		case "callerip":
+			b.WriteString(h.CallerIp)
+		case "hostip":
+			b.WriteString(h.HostIp)
+		case "caller":
+			b.WriteString(shortFileName(e.file))
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(e.line))
+		case "file":
+			b.WriteString(shortFileName(e.file))
+		case "line":
+			b.WriteString(strconv.Itoa(e.line))
+		case "tag":
+			b.WriteString(e.tag)
+		case "msg":
+			fmt.Fprintf(&b, "%v", e.msg)
+		case "fields":
+			appendText(&b, e.fields)
+		case "stack":
+			for _, frame := range h.stack {
+				b.WriteString("\n\t")
+				b.WriteString(frame)
+			}
+		}
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_layout(t *testing.T) {
+	l, err := newLayout(&LogConfig{
+		Pattern:    "%time %level %logid %module %callerip %caller %msg",
+		TimeFormat: time.RFC3339,
+		TimeZone:   "UTC",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &entry{
+		level:  WARN,
+		time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600)),
+		header: LogHeader{LogId: "id", Module: "/a", CallerIp: "1.2.3.4"},
+		file:   "/src/main.go",
+		line:   7,
+		msg:    "hello",
+		fields: []Field{Int("n", 1)},
+	}
+	want := "2020-01-01T19:04:05Z WARN id /a 1.2.3.4 main.go:7 hello n=1\n"
+	if got := string(l.format(e)); got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+	if l, _ := newLayout(&LogConfig{}); l != defaultLayout {
+		t.Errorf("sinks without options should share the default layout")
+	}
+}
+
+func Test_layoutTimeZoneError(t *testing.T) {
+	var reported int
+	l, err := NewBaseLogger(&LogConfig{Sink: &memSink{}, TimeZone: "Nowhere/City", OnError: func(error) { reported++ }})
+	defer l.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Nowhere/City") || reported != 1 {
+		t.Errorf("unknown time zone not reported: %v", err)
+	}
+}
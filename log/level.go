@@ -0,0 +1,180 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParseLevel accepts a level name such as "debug" or its number.
+func ParseLevel(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := levelStr[n]; ok {
+			return n, nil
+		}
+	}
+	for level, name := range levelStr {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// SetLevel changes the level of the default logger at runtime.
+func SetLevel(level int) {
+	Default().SetLevel(level)
+}
+
+// GetLevel returns the level of the default logger.
+func GetLevel() int {
+	return Default().GetLevel()
+}
+
+// SetModuleLevel overrides the level for entries whose LogHeader.Module
+// is module, e.g. the url path of a rest route.
+func SetModuleLevel(module string, level int) {
+	Default().SetModuleLevel(module, level)
+}
+
+// ResetModuleLevel removes the override of module.
+func ResetModuleLevel(module string) {
+	Default().ResetModuleLevel(module)
+}
+
+// ModuleLevels returns a copy of the module overrides.
+func ModuleLevels() map[string]int {
+	return Default().ModuleLevels()
+}
+
+func (l *BaseLogger) SetLevel(level int) {
+	l.logLevel.Store(int32(level))
+}
+
+func (l *BaseLogger) GetLevel() int {
+	return int(l.logLevel.Load())
+}
+
+func (l *BaseLogger) SetModuleLevel(module string, level int) {
+	l.updateModules(func(m map[string]int) {
+		m[module] = level
+	})
+}
+
+func (l *BaseLogger) ResetModuleLevel(module string) {
+	l.updateModules(func(m map[string]int) {
+		delete(m, module)
+	})
+}
+
+func (l *BaseLogger) ModuleLevels() map[string]int {
+	m := make(map[string]int)
+	if cur := l.modules.Load(); cur != nil {
+		for k, v := range *cur {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// updateModules replaces the override map with a modified copy, so
+// readers on the log path never lock.
+func (l *BaseLogger) updateModules(f func(map[string]int)) {
+	for {
+		old := l.modules.Load()
+		m := make(map[string]int)
+		if old != nil {
+			for k, v := range *old {
+				m[k] = v
+			}
+		}
+		f(m)
+		if l.modules.CompareAndSwap(old, &m) {
+			return
+		}
+	}
+}
+
+// levelFor returns the primary sink level for module.
+func (l *BaseLogger) levelFor(module string) int {
+	if m := l.modules.Load(); m != nil {
+		if level, ok := (*m)[module]; ok {
+			return level
+		}
+	}
+	return int(l.logLevel.Load())
+}
+
+// enabled reports whether an entry reaches at least one sink.
+func (l *BaseLogger) enabled(level int, module string) bool {
+	return level >= l.sinkLevel || level >= l.levelFor(module)
+}
+
+type levelRequest struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+}
+
+type levelResponse struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// LevelHandler is the admin endpoint of the default logger, see
+// (*BaseLogger).LevelHandler. The logger installed when a request
+// arrives is the one changed.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default().serveLevel(w, r)
+	})
+}
+
+// LevelHandler is an admin endpoint changing the levels of l:
+//
+//	GET                                 current levels
+//	PUT/POST {"level":"DEBUG"}          set the global level
+//	PUT/POST {"module":"/a","level":..} set the level of a module
+//	DELETE ?module=/a                   remove the override of a module
+//
+// It has no authentication, serve it on an admin only listener.
+func (l *BaseLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(l.serveLevel)
+}
+
+func (l *BaseLogger) serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "decode request failed:"+err.Error(), http.StatusBadRequest)
+			return
+		}
+		level, err := ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Module == "" {
+			l.SetLevel(level)
+		} else {
+			l.SetModuleLevel(req.Module, level)
+		}
+	case http.MethodDelete:
+		l.ResetModuleLevel(r.URL.Query().Get("module"))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := levelResponse{
+		Level:   levelStr[l.GetLevel()],
+		Modules: make(map[string]string),
+	}
+	for module, level := range l.ModuleLevels() {
+		resp.Modules[module] = levelStr[level]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_moduleLevel(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Level: INFO})
+	a := LogHeader{Module: "/a"}
+	b := LogHeader{Module: "/b"}
+	l.Debug(a, "dropped")
+	l.SetModuleLevel("/a", DEBUG)
+	l.Debug(a, "kept")
+	l.Debug(b, "dropped")
+	l.ResetModuleLevel("/a")
+	l.Debug(a, "dropped")
+	l.SetLevel(ERROR)
+	l.Info(b, "dropped")
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 1 || !strings.Contains(mem.lines[0], "MSG:kept") {
+		t.Errorf("unexpected lines %v", mem.lines)
+	}
+}
+
+func Test_LevelHandler(t *testing.T) {
+	old := Default()
+	SetDefault(newLogger(&LogConfig{Sink: &memSink{}, Level: INFO}))
+	defer SetDefault(old)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/admin/log", strings.NewReader(`{"module":"/a","level":"debug"}`))
+	LevelHandler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"/a":"DEBUG"`) {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if GetLevel() != INFO || ModuleLevels()["/a"] != DEBUG {
+		t.Errorf("level not applied")
+	}
+}
+
+func Test_instanceLevelHandler(t *testing.T) {
+	l := newLogger(&LogConfig{Sink: &memSink{}, Level: INFO})
+	defer l.Shutdown(context.Background())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/admin/access", strings.NewReader(`{"level":"warn"}`))
+	l.LevelHandler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || l.GetLevel() != WARN {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if GetLevel() == WARN {
+		t.Errorf("default logger changed")
+	}
+}
@@ -3,17 +3,28 @@ package log
 import (
     "net/http"
     "strings"
-    "testing"
 
     "github.com/skadilover/easykit/log/logid"
 )
 
 type Logger interface {
+    Debug(format string, v ...interface{})
+    Warn(format string, v ...interface{})
     Error(format string, v ...interface{})
     Info(format string, v ...interface{})
+    //Panic panics after the entry is flushed, Fatal exits the process
+    Panic(format string, v ...interface{})
+    Fatal(format string, v ...interface{})
     Tag(tag string, msg interface{})
     Logid() string
     Head() LogHeader
+    //key/value pairs, Field values may be mixed in
+    Debugw(msg string, kv ...interface{})
+    Warnw(msg string, kv ...interface{})
+    Errorw(msg string, kv ...interface{})
+    Infow(msg string, kv ...interface{})
+    //child logger whose entries carry kv besides the parent's fields
+    With(kv ...interface{}) Logger
 }
 
 func getRealIp(r *http.Request) string {
@@ -28,26 +39,55 @@ func getRealIp(r *http.Request) string {
     return remoteAddr
 }
 
+//request scoped logger of the default logger
 func GetHttpLogger(r *http.Request) Logger {
+    return &httpLogger{h: httpHeader(r)}
+}
+
+//request scoped logger writing to l instead of the default logger
+func (l *BaseLogger) HttpLogger(r *http.Request) Logger {
+    return &httpLogger{h: httpHeader(r), base: l}
+}
+
+//Logger binds h to l
+func (l *BaseLogger) Logger(h LogHeader) Logger {
+    return &httpLogger{h: h, base: l}
+}
+
+func httpHeader(r *http.Request) LogHeader {
     logId := r.URL.Query().Get("logid")
     if logId == "" {
         logId = logid.NewObjectId().Hex()
     }
     cid := r.URL.Query().Get("cid")
     module := r.URL.Path
-    return &httpLogger{
-        h: LogHeader{
-            LogId:    logId + "###" + cid,
-            Module:   module,
-            Lat:      r.URL.Query().Get("lat"),
-            Lng:      r.URL.Query().Get("lng"),
-            CallerIp: getRealIp(r),
-        },
+    return LogHeader{
+        LogId:    logId + "###" + cid,
+        Module:   module,
+        Lat:      r.URL.Query().Get("lat"),
+        Lng:      r.URL.Query().Get("lng"),
+        CallerIp: getRealIp(r),
     }
 }
 
 type httpLogger struct {
     h LogHeader
+    //nil writes to the default logger
+    base *BaseLogger
+}
+
+func (l *httpLogger) logger() *BaseLogger {
+    if l.base != nil {
+        return l.base
+    }
+    return Default()
+}
+
+//head is the header for the logger methods, skipping the httpLogger frame
+func (l *httpLogger) head() LogHeader {
+    h := l.h
+    h.skip++
+    return h
 }
 
 func (l *httpLogger) Logid() string {
@@ -58,44 +98,85 @@ func (l *httpLogger) Head() LogHeader {
     return l.h
 }
 
+func (l *httpLogger) Debug(format string, v ...interface{}) {
+    l.logger().Debug(l.head(), format, v...)
+}
+
+func (l *httpLogger) Warn(format string, v ...interface{}) {
+    l.logger().Warn(l.head(), format, v...)
+}
+
+func (l *httpLogger) Panic(format string, v ...interface{}) {
+    l.logger().Panic(l.head(), format, v...)
+}
+
+func (l *httpLogger) Fatal(format string, v ...interface{}) {
+    l.logger().Fatal(l.head(), format, v...)
+}
+
 func (l *httpLogger) Error(format string, v ...interface{}) {
-    Error(l.h, format, v...)
+    l.logger().Error(l.head(), format, v...)
 
 }
 
 func (l *httpLogger) Info(format string, v ...interface{}) {
-    Info(l.h, format, v...)
+    l.logger().Info(l.head(), format, v...)
 }
 
 func (l *httpLogger) Tag(tag string, msg interface{}) {
-    Tag(l.h, tag, msg)
+    l.logger().Tag(l.head(), tag, msg)
 }
 
-func GetTestLogger(t *testing.T) Logger {
-    return &testLogger{t: t}
+func (l *httpLogger) Debugw(msg string, kv ...interface{}) {
+    l.logger().Debugw(l.head(), msg, kv...)
 }
 
-func (l *testLogger) Logid() string {
-    return "test"
+func (l *httpLogger) Warnw(msg string, kv ...interface{}) {
+    l.logger().Warnw(l.head(), msg, kv...)
 }
 
-func (l *testLogger) Head() LogHeader {
-    return LogHeader{}
+func (l *httpLogger) Errorw(msg string, kv ...interface{}) {
+    l.logger().Errorw(l.head(), msg, kv...)
 }
 
-type testLogger struct {
-    t *testing.T
+func (l *httpLogger) Infow(msg string, kv ...interface{}) {
+    l.logger().Infow(l.head(), msg, kv...)
 }
 
-func (l *testLogger) Error(format string, v ...interface{}) {
-    l.t.Errorf(format, v...)
+func (l *httpLogger) With(kv ...interface{}) Logger {
+    return &httpLogger{h: withFields(l.h, toFields(kv)), base: l.base}
+}
 
+func (l *httpLogger) Redact(data []byte) []byte {
+    return l.logger().Redact(data)
 }
 
-func (l *testLogger) Info(format string, v ...interface{}) {
-    l.t.Logf(format, v...)
+func (l *httpLogger) RedactQuery(query string) string {
+    return l.logger().RedactQuery(query)
 }
 
-func (l *testLogger) Tag(tag string, msg interface{}) {
-    l.t.Log(tag, msg)
+func (l *httpLogger) Body(data []byte, contentType string, max int) string {
+    return l.logger().Body(data, contentType, max)
+}
+
+func (l *httpLogger) Recovered(v interface{}) {
+    l.logger().Recovered(l.head(), v)
+}
+
+func (l *httpLogger) WithCallerSkip(skip int) Logger {
+    h := l.h
+    h.skip += skip
+    return &httpLogger{h: h, base: l.base}
+}
+
+//AddCallerSkip returns a Logger reporting the caller skip frames above
+//the one calling it, for functions wrapping a Logger. Loggers that do
+//not support it are returned as is.
+func AddCallerSkip(l Logger, skip int) Logger {
+    if s, ok := l.(interface {
+        WithCallerSkip(skip int) Logger
+    }); ok {
+        return s.WithCallerSkip(skip)
+    }
+    return l
 }
@@ -0,0 +1,54 @@
+package log
+
+// Dropped returns how many entries the default logger has dropped
+// because its buffer was full.
+func Dropped() uint64 {
+	return Default().Dropped()
+}
+
+// Dropped returns how many entries were dropped by the overflow policy.
+func (l *BaseLogger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// enqueue puts e on logChan, applying the overflow policy when the
+// buffer is full.
+func (l *BaseLogger) enqueue(e *entry) {
+	if l.conf.Overflow == OVERFLOW_BLOCK {
+		l.logChan <- e
+		return
+	}
+	select {
+	case l.logChan <- e:
+		return
+	default:
+	}
+	switch l.conf.Overflow {
+	case OVERFLOW_DROP_OLDEST:
+		l.replaceOldest(e)
+	case OVERFLOW_SAMPLE:
+		rate := uint64(l.conf.SampleRate)
+		if rate > 0 && l.overflowed.Add(1)%rate == 0 {
+			l.replaceOldest(e)
+			return
+		}
+		l.dropped.Add(1)
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// replaceOldest makes room for e by dropping the oldest entry, it never
+// blocks: e is dropped when other callers took the room first.
+func (l *BaseLogger) replaceOldest(e *entry) {
+	select {
+	case <-l.logChan:
+		l.dropped.Add(1)
+	default:
+	}
+	select {
+	case l.logChan <- e:
+	default:
+		l.dropped.Add(1)
+	}
+}
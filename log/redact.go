@@ -0,0 +1,348 @@
+package log
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+*脱敏规则,Key按字段名在任意层级匹配(不区分大小写),
+*Path按从根开始的点分路径匹配,*匹配任意key或数组下标,如items.*.token
+ */
+const (
+	REDACT_MASK = iota
+	REDACT_HASH
+	REDACT_TRUNCATE
+)
+
+type RedactRule struct {
+	Key  string
+	Path string
+	//REDACT_MASK(default), REDACT_HASH or REDACT_TRUNCATE
+	Action int
+	//characters kept, the tail for REDACT_MASK and the head for REDACT_TRUNCATE
+	Keep int
+	//secret of REDACT_HASH, defaults to LogConfig.RedactHashKey. Without
+	//a key the hash is a plain sha256, which is reversed by hashing
+	//every candidate of small value spaces such as phone numbers.
+	HashKey string
+}
+
+// Redactor applies RedactRules to json bodies and fields.
+type Redactor struct {
+	keys  map[string]*RedactRule
+	paths []redactPath
+	//HashKey of rules without one
+	hashKey string
+}
+
+type redactPath struct {
+	segs []string
+	rule *RedactRule
+}
+
+// NewRedactor compiles rules, it returns nil when there is none.
+func NewRedactor(rules []*RedactRule) *Redactor {
+	if len(rules) == 0 {
+		return nil
+	}
+	r := &Redactor{keys: make(map[string]*RedactRule)}
+	for _, rule := range rules {
+		if rule.Key != "" {
+			r.keys[strings.ToLower(rule.Key)] = rule
+		}
+		if rule.Path != "" {
+			r.paths = append(r.paths, redactPath{segs: strings.Split(rule.Path, "."), rule: rule})
+		}
+	}
+	return r
+}
+
+// newRedactor compiles the rules of c with its RedactHashKey.
+func newRedactor(c *LogConfig) *Redactor {
+	r := NewRedactor(c.Redact)
+	if r != nil {
+		r.hashKey = c.RedactHashKey
+	}
+	return r
+}
+
+// Redact returns data with the rules of l applied, for request and
+// response bodies. data is returned as is when l has no rules or it
+// is not a json object or array.
+func Redact(l Logger, data []byte) []byte {
+	if r, ok := l.(interface {
+		Redact(data []byte) []byte
+	}); ok {
+		return r.Redact(data)
+	}
+	return data
+}
+
+func (l *BaseLogger) Redact(data []byte) []byte {
+	return l.redactor.JSON(data)
+}
+
+// RedactQuery returns the raw query of a url with the rules of l applied
+// to its values, by the names of the parameters, for access logs.
+func RedactQuery(l Logger, query string) string {
+	if r, ok := l.(interface {
+		RedactQuery(query string) string
+	}); ok {
+		return r.RedactQuery(query)
+	}
+	return query
+}
+
+func (l *BaseLogger) RedactQuery(query string) string {
+	return l.redactor.Query(query)
+}
+
+// Query redacts the values of a raw url query whose parameter names match
+// a Key rule or a Path of one segment. The other parameters are kept as
+// they are, escaping included.
+func (r *Redactor) Query(query string) string {
+	if r == nil || query == "" {
+		return query
+	}
+	parts := strings.Split(query, "&")
+	for i, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+		rule := r.match([]string{name})
+		if rule == nil {
+			continue
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		parts[i] = key + "=" + queryUnescaper.Replace(url.QueryEscape(fmt.Sprint(r.apply(rule, value))))
+	}
+	return strings.Join(parts, "&")
+}
+
+// keeps masks and hash prefixes readable, both are valid in a query
+var queryUnescaper = strings.NewReplacer("%2A", "*", "%3A", ":")
+
+// JSON redacts a json object or array, other data and data no rule
+// matches are returned as is.
+func (r *Redactor) JSON(data []byte) []byte {
+	if d, ok := r.json(data); ok {
+		return d
+	}
+	return data
+}
+
+// json reports whether a rule matched data.
+func (r *Redactor) json(data []byte) ([]byte, bool) {
+	if r == nil {
+		return nil, false
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' && trimmed[0] != '[' {
+		return nil, false
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	v, ok := r.walk(v, nil)
+	if !ok {
+		return nil, false
+	}
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return d, true
+}
+
+// Fields returns fields with the rules applied, fields is not modified.
+func (r *Redactor) Fields(fields []Field) []Field {
+	if r == nil || len(fields) == 0 {
+		return fields
+	}
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		out[i] = Field{Key: f.Key, Value: r.value([]string{f.Key}, f.Value)}
+	}
+	return out
+}
+
+// Value redacts an arbitrary value such as the msg of Tag, values
+// other than strings are walked through their json form. Strings are
+// not redacted, and v is returned as is when no rule matches.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+	switch x := v.(type) {
+	case nil, string, error:
+		return v
+	case []byte:
+		if d, ok := r.json(x); ok {
+			return string(d)
+		}
+		return v
+	}
+	if t, ok := r.walk(toTree(v), nil); ok {
+		return t
+	}
+	return v
+}
+
+func (r *Redactor) value(path []string, v interface{}) interface{} {
+	if rule := r.match(path); rule != nil {
+		return r.apply(rule, v)
+	}
+	if r.hasPrefix(path) {
+		if t, ok := r.walk(toTree(v), path); ok {
+			return t
+		}
+	}
+	return v
+}
+
+// walk applies the rules to a tree decoded from json, it reports
+// whether a rule matched.
+func (r *Redactor) walk(v interface{}, path []string) (interface{}, bool) {
+	matched := false
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, child := range x {
+			p := append(path[:len(path):len(path)], k)
+			if rule := r.match(p); rule != nil {
+				m[k], matched = r.apply(rule, child), true
+				continue
+			}
+			var ok bool
+			m[k], ok = r.walk(child, p)
+			matched = matched || ok
+		}
+		return m, matched
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, child := range x {
+			var ok bool
+			a[i], ok = r.walk(child, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			matched = matched || ok
+		}
+		return a, matched
+	default:
+		return v, false
+	}
+}
+
+func (r *Redactor) match(path []string) *RedactRule {
+	if rule, ok := r.keys[strings.ToLower(path[len(path)-1])]; ok {
+		return rule
+	}
+	for _, p := range r.paths {
+		if len(p.segs) == len(path) && matchSegs(p.segs, path) {
+			return p.rule
+		}
+	}
+	return nil
+}
+
+// hasPrefix reports whether a rule may match below path.
+func (r *Redactor) hasPrefix(path []string) bool {
+	if len(r.keys) > 0 {
+		return true
+	}
+	for _, p := range r.paths {
+		if len(p.segs) > len(path) && matchSegs(p.segs[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegs(segs, path []string) bool {
+	for i, s := range segs {
+		if s != "*" && s != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// toTree converts v to maps and slices through json.
+func toTree(v interface{}) interface{} {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return v
+	}
+	d, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var t interface{}
+	dec := json.NewDecoder(bytes.NewReader(d))
+	dec.UseNumber()
+	if err := dec.Decode(&t); err != nil {
+		return v
+	}
+	return t
+}
+
+// apply redacts v by rule. REDACT_HASH is an HMAC-SHA256 keyed with the
+// HashKey of rule or r, "hmac:" and its first 8 bytes in hex. Without
+// a key it is "sha256:" and the first 8 bytes of a plain sha256.
+func (r *Redactor) apply(rule *RedactRule, v interface{}) interface{} {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	default:
+		d, _ := json.Marshal(v)
+		s = string(d)
+	}
+	switch rule.Action {
+	case REDACT_HASH:
+		key := rule.HashKey
+		if key == "" {
+			key = r.hashKey
+		}
+		if key == "" {
+			sum := sha256.Sum256([]byte(s))
+			return "sha256:" + hex.EncodeToString(sum[:8])
+		}
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	case REDACT_TRUNCATE:
+		rs := []rune(s)
+		if len(rs) <= rule.Keep {
+			return s
+		}
+		return string(rs[:rule.Keep]) + "..."
+	default:
+		rs := []rune(s)
+		keep := rule.Keep
+		if keep >= len(rs) {
+			keep = 0
+		}
+		return strings.Repeat("*", len(rs)-keep) + string(rs[len(rs)-keep:])
+	}
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_RedactorJSON(t *testing.T) {
+	r := NewRedactor([]*RedactRule{
+		{Key: "phone", Keep: 4},
+		{Key: "Token", Action: REDACT_HASH},
+		{Path: "items.*.card", Action: REDACT_TRUNCATE, Keep: 2},
+	})
+	got := string(r.JSON([]byte(`{"user":{"PHONE":"13812345678"},"token":"abc","items":[{"card":"622588","n":1}],"card":"kept"}`)))
+	for _, want := range []string{`"PHONE":"*******5678"`, `"token":"sha256:ba7816bf8f01cfea"`, `"card":"62..."`, `"n":1`, `"card":"kept"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s missing in %s", want, got)
+		}
+	}
+	keyed := newRedactor(&LogConfig{RedactHashKey: "k", Redact: []*RedactRule{{Key: "token", Action: REDACT_HASH}}})
+	//hex of the first 8 bytes of HMAC-SHA256("k", "abc")
+	if got := string(keyed.JSON([]byte(`{"token":"abc"}`))); got != `{"token":"hmac:342e519ce0ad6c03"}` {
+		t.Errorf("unexpected keyed hash %s", got)
+	}
+	if got := string(r.JSON([]byte("plain text"))); got != "plain text" {
+		t.Errorf("non json body changed: %s", got)
+	}
+	var none *Redactor
+	if got := string(none.JSON([]byte(`{"phone":"1"}`))); got != `{"phone":"1"}` {
+		t.Errorf("nil redactor changed body: %s", got)
+	}
+}
+
+func Test_redactEntry(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Mode: MOD_JSON, Redact: []*RedactRule{{Key: "password"}}})
+	h := LogHeader{LogId: "redact"}
+	l.Infow(h, "login", "password", "secret", "user", map[string]string{"name": "bob", "password": "x"})
+	l.Tag(h, "body", map[string]interface{}{"password": "secret"})
+	hl := l.Logger(h)
+	body := Redact(hl, []byte(`{"password":"secret"}`))
+	l.Shutdown(context.Background())
+	if string(body) != `{"password":"******"}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	for _, line := range mem.lines {
+		if strings.Contains(line, "secret") || strings.Contains(line, `"password":"x"`) {
+			t.Errorf("not redacted: %s", line)
+		}
+	}
+}
+
+func Test_redactUnmatchedValue(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Redact: []*RedactRule{{Key: "password"}}})
+	l.Tag(LogHeader{LogId: "redact"}, "tag", struct{ A int }{1})
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 1 || !strings.Contains(mem.lines[0], "{1}") {
+		t.Errorf("unmatched value changed: %v", mem.lines)
+	}
+}
+
+func Test_redactQuery(t *testing.T) {
+	l := newLogger(&LogConfig{Sink: &memSink{}, Redact: []*RedactRule{{Key: "token"}, {Path: "card", Action: REDACT_TRUNCATE, Keep: 2}}})
+	defer l.Shutdown(context.Background())
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"a=1&b=2", "a=1&b=2"},
+		{"TOKEN=abc&n=%2F", "TOKEN=***&n=%2F"},
+		{"token=a%2Bb&token=x", "token=***&token=*"},
+		{"card=622588&flag", "card=62...&flag"},
+		{"token", "token"},
+	}
+	for _, c := range cases {
+		if got := RedactQuery(l.Logger(LogHeader{}), c.query); got != c.want {
+			t.Errorf("RedactQuery(%q) expect %q, got %q", c.query, c.want, got)
+		}
+	}
+}
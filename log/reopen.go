@@ -0,0 +1,82 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// how often ROTATE_EXTERNAL looks for a moved or deleted file
+const reopenCheckInterval = time.Second
+
+// Reopen reopens the files of the default logger.
+func Reopen() error {
+	return Default().Reopen()
+}
+
+// Reopen reopens the file sinks, and the custom sinks having a Reopen
+// method, after an external tool like logrotate moved their files.
+// A sink keeps its current file when the new one cannot be opened.
+func (l *BaseLogger) Reopen() error {
+	l.state.RLock()
+	defer l.state.RUnlock()
+	if l.closed {
+		return nil
+	}
+	var err error
+	for _, s := range l.sinks {
+		r, ok := s.Sink.(interface {
+			Reopen() error
+		})
+		if !ok {
+			continue
+		}
+		if rerr := r.Reopen(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func (l *fileSink) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reopen()
+}
+
+// reopen opens the file at the path, the current one is kept when it
+// fails and stderr is written to when there is none.
+func (l *fileSink) reopen() error {
+	path := joinFilePath(l.fileDir, l.fileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		if l.logFile == nil {
+			l.resetWriter()
+		}
+		return fmt.Errorf("logger open %s: %w", path, err)
+	}
+	if l.logFile != nil {
+		l.logFile.Close()
+	}
+	l.logFile = f
+	l.resetWriter()
+	return nil
+}
+
+// checkMoved reopens the file once it is no longer the one at the path,
+// at most every reopenCheckInterval.
+func (l *fileSink) checkMoved() {
+	now := time.Now()
+	if now.Sub(l.checked) < reopenCheckInterval {
+		return
+	}
+	l.checked = now
+	if fi, err := os.Stat(joinFilePath(l.fileDir, l.fileName)); err == nil && l.logFile != nil {
+		if cur, err := l.logFile.Stat(); err == nil && os.SameFile(fi, cur) {
+			return
+		}
+	}
+	if err := l.reopen(); err != nil {
+		l.report(err)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignal reopens the files on SIGHUP until the logger is shut down.
+func (l *BaseLogger) watchSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				if err := l.Reopen(); err != nil {
+					l.reportError(fmt.Errorf("logger reopen: %w", err))
+				}
+			case <-l.quit:
+				return
+			}
+		}
+	}()
+}
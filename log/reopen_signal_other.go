@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	"log"
+)
+
+func (l *BaseLogger) watchSignal() {
+	log.Printf("logger reopen on signal is not supported on this platform")
+}
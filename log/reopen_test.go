@@ -0,0 +1,47 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reopen.log")
+	l := newLogger(&LogConfig{Path: dir, Name: "reopen.log", Rotate: ROTATE_EXTERNAL})
+	h := LogHeader{LogId: "reopen"}
+	l.Info(h, "first")
+	l.Sync()
+
+	//logrotate moves the file and signals the process
+	os.Rename(path, path+".1")
+	l.Info(h, "to moved file")
+	l.Sync()
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("reopen failed:%s", err.Error())
+	}
+	l.Info(h, "second")
+	l.Sync()
+
+	//deleted without a signal, noticed on the next write
+	os.Remove(path)
+	l.fileSink.checked = time.Time{}
+	l.Info(h, "third")
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed:%s", err.Error())
+	}
+
+	for name, want := range map[string]int{"reopen.log.1": 2, "reopen.log": 1} {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		if n := strings.Count(string(data), "\n"); n != want {
+			t.Errorf("expect %d lines in %s, got %d", want, name, n)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expect no rotated files, got %d files", len(entries))
+	}
+}
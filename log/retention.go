@@ -0,0 +1,156 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const compressSuffix = ".gz"
+
+// needCleanup reports whether rotated files have to be post-processed.
+func (l *fileSink) needCleanup() bool {
+	return l.conf.MaxBackups > 0 || l.conf.MaxAge > 0 || l.conf.Compress
+}
+
+// notifyCleanup wakes up cleanupLoop without blocking the caller, a
+// pending notification already covers the new backup.
+func (l *fileSink) notifyCleanup() {
+	if l.cleanChan == nil {
+		return
+	}
+	select {
+	case l.cleanChan <- struct{}{}:
+	default:
+	}
+}
+
+// cleanupLoop compresses and removes rotated files in the background,
+// so writeLog is never blocked by disk work on old files.
+func (l *fileSink) cleanupLoop(c <-chan struct{}) {
+	for range c {
+		l.cleanBackups()
+	}
+}
+
+type backupFile struct {
+	path    string
+	modTime time.Time
+}
+
+// isBackup reports whether suffix, the part of a file name after the
+// log name and the dot, is name.YYYY-MM-DD[-HH][_N][.gz] of a backup.
+// Other files sharing the prefix, like app.access of app, are not.
+func isBackup(suffix string) bool {
+	suffix = strings.TrimSuffix(suffix, compressSuffix)
+	if i := strings.LastIndexByte(suffix, '_'); i >= 0 {
+		if _, err := strconv.Atoi(suffix[i+1:]); err != nil {
+			return false
+		}
+		suffix = suffix[:i]
+	}
+	for _, format := range []string{DATEFORMAT, HOURFORMAT} {
+		if _, err := time.Parse(format, suffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// backups lists the rotated files of l, newest first.
+func (l *fileSink) backups() []backupFile {
+	prefix := joinFilePath(l.fileDir, l.fileName) + "."
+	matches, err := filepath.Glob(prefix + "[0-9]*")
+	if err != nil {
+		return nil
+	}
+	files := make([]backupFile, 0, len(matches))
+	for _, m := range matches {
+		if !isBackup(strings.TrimPrefix(m, prefix)) {
+			continue
+		}
+		fi, err := os.Stat(m)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		files = append(files, backupFile{path: m, modTime: fi.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files
+}
+
+func (l *fileSink) cleanBackups() {
+	files := l.backups()
+	var keep []backupFile
+	for i, f := range files {
+		if l.conf.MaxBackups > 0 && i >= l.conf.MaxBackups {
+			os.Remove(f.path)
+			continue
+		}
+		if l.conf.MaxAge > 0 && time.Since(f.modTime) > time.Duration(l.conf.MaxAge)*24*time.Hour {
+			os.Remove(f.path)
+			continue
+		}
+		keep = append(keep, f)
+	}
+	if !l.conf.Compress {
+		return
+	}
+	for _, f := range keep {
+		if strings.HasSuffix(f.path, compressSuffix) {
+			continue
+		}
+		if err := compressFile(f.path); err != nil {
+			l.report(fmt.Errorf("logger compress %s: %w", f.path, err))
+		}
+	}
+}
+
+// compressFile gzips src to src.gz and removes src. The modification
+// time is kept so that retention still orders backups correctly. An
+// existing src.gz is never overwritten, src is left as it is then.
+func compressFile(src string) error {
+	dst := src + compressSuffix
+	if isExist(dst) {
+		return fmt.Errorf("%s: %w", dst, os.ErrExist)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+	return os.Remove(src)
+}
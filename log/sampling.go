@@ -0,0 +1,217 @@
+package log
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//identical entries come from the same file:line
+	SAMPLE_BY_CALLER = iota
+	//identical entries share the level and format string or message
+	SAMPLE_BY_TEMPLATE
+)
+
+// max call sites counted per interval, the counters start over when
+// it is exceeded so that dynamic templates cannot grow them forever
+const maxSampleKeys = 10000
+
+// sites reported by name in a summary line, the rest are only counted
+const maxSummarySites = 20
+
+// SamplingConfig thins out repeated entries. Within every Interval the
+// first First entries of a call site are logged, after that every
+// Thereafter-th one. RateLimit caps the entries per second of a level
+// whatever their call site. PANIC and FATAL are never suppressed.
+//
+// Suppressed entries are reported by a WARN summary line once per
+// Interval, see Suppressed.
+type SamplingConfig struct {
+	//entries of a call site logged per interval, 0 disables sampling
+	First int
+	//log every Thereafter-th entry after First, 0 drops them all
+	Thereafter int
+	//seconds, defaults to 1
+	Interval int64
+	//SAMPLE_BY_CALLER(default) or SAMPLE_BY_TEMPLATE, entries without
+	//a template such as InfoJson are keyed by call site either way
+	By int
+	//max entries per second by level name such as "info", 0 is unlimited
+	RateLimit map[string]int
+}
+
+type sampler struct {
+	conf     *SamplingConfig
+	interval time.Duration
+	limits   [FATAL + 1]int
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[string]int
+	//second of the rate limiter and entries logged in it per level
+	second int64
+	rates  [FATAL + 1]int
+	//suppressed entries by call site since the last summary
+	suppressed map[string]int
+
+	total atomic.Uint64
+}
+
+func newSampler(c *SamplingConfig) *sampler {
+	if c == nil {
+		return nil
+	}
+	s := &sampler{
+		conf:       c,
+		interval:   time.Duration(c.Interval) * time.Second,
+		counts:     make(map[string]int),
+		suppressed: make(map[string]int),
+	}
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+	for name, n := range c.RateLimit {
+		level, err := ParseLevel(name)
+		if err != nil || n <= 0 {
+			continue
+		}
+		s.limits[level] = n
+	}
+	return s
+}
+
+// Suppressed returns how many entries the default logger has
+// suppressed by sampling and rate limiting.
+func Suppressed() uint64 {
+	return Default().Suppressed()
+}
+
+// Suppressed returns how many entries were suppressed by sampling and
+// rate limiting.
+func (l *BaseLogger) Suppressed() uint64 {
+	if l.sampler == nil {
+		return 0
+	}
+	return l.sampler.total.Load()
+}
+
+// sample reports whether an entry of level logged at file:line with
+// template passes sampling and rate limiting.
+func (l *BaseLogger) sample(level int, file string, line int, template string) bool {
+	if l.sampler == nil || level >= PANIC {
+		return true
+	}
+	return l.sampler.allow(level, file, line, template)
+}
+
+func (s *sampler) allow(level int, file string, line int, template string) bool {
+	//call sites are keyed by the full path, handler.go:42 of two
+	//packages are different sites
+	var key string
+	byCaller := s.conf.By != SAMPLE_BY_TEMPLATE || template == ""
+	if byCaller {
+		key = file + ":" + strconv.Itoa(line)
+	} else {
+		key = levelStr[level] + " " + template
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.window) >= s.interval || len(s.counts) >= maxSampleKeys {
+		s.window = now
+		s.counts = make(map[string]int)
+	}
+	limit := s.limits[level]
+	if limit > 0 {
+		if sec := now.Unix(); sec != s.second {
+			s.second = sec
+			s.rates = [FATAL + 1]int{}
+		}
+		if s.rates[level] >= limit {
+			s.suppress(levelStr[level] + " rate limit")
+			return false
+		}
+	}
+	if first := s.conf.First; first > 0 {
+		n := s.counts[key] + 1
+		s.counts[key] = n
+		if n > first && (s.conf.Thereafter <= 0 || (n-first)%s.conf.Thereafter != 0) {
+			//the summary names sites by the short file name
+			if byCaller {
+				key = shortFileName(file) + ":" + strconv.Itoa(line)
+			}
+			s.suppress(key)
+			return false
+		}
+	}
+	if limit > 0 {
+		s.rates[level]++
+	}
+	return true
+}
+
+func (s *sampler) suppress(key string) {
+	s.total.Add(1)
+	if _, ok := s.suppressed[key]; ok || len(s.suppressed) < maxSampleKeys {
+		s.suppressed[key]++
+	}
+}
+
+// take returns and resets the suppressed entries by call site.
+func (s *sampler) take() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.suppressed) == 0 {
+		return nil
+	}
+	m := s.suppressed
+	s.suppressed = make(map[string]int)
+	return m
+}
+
+// summaryLoop logs what was suppressed during the last interval until
+// the logger is shut down.
+func (l *BaseLogger) summaryLoop() {
+	ticker := time.NewTicker(l.sampler.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.summary()
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+func (l *BaseLogger) summary() {
+	m := l.sampler.take()
+	if m == nil || !l.enabled(WARN, "") {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	total := 0
+	for k, n := range m {
+		keys = append(keys, k)
+		total += n
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > maxSummarySites {
+		keys = keys[:maxSummarySites]
+	}
+	sites := make(map[string]int, len(keys))
+	for _, k := range keys {
+		sites[k] = m[k]
+	}
+	msg := "sampling suppressed " + strconv.Itoa(total) + " log entries"
+	l.emit(WARN, LogHeader{}, "sampling", 0, "", msg, Int("suppressed", total), Any("sites", sites))
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_sampling(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{
+		Sink:     mem,
+		Mode:     MOD_JSON,
+		Sampling: &SamplingConfig{First: 3, Thereafter: 5, Interval: 60},
+	})
+	h := LogHeader{LogId: "sampling"}
+	for i := 0; i < 20; i++ {
+		l.Info(h, "hot %d", i)
+	}
+	l.Info(h, "cold")
+	//3 first + 5th, 10th and 15th of the remaining 17
+	if n := l.Suppressed(); n != 14 {
+		t.Errorf("expect 14 suppressed entries, got %d", n)
+	}
+	l.summary()
+	l.Sync()
+	if len(mem.lines) != 8 {
+		t.Fatalf("expect 8 lines, got %d: %v", len(mem.lines), mem.lines)
+	}
+	last := mem.lines[7]
+	if !strings.Contains(last, `"suppressed":14`) || !strings.Contains(last, "sampling_test.go:") {
+		t.Errorf("unexpected summary %s", last)
+	}
+	l.Shutdown(context.Background())
+}
+
+func Test_rateLimit(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{
+		Sink: mem,
+		Sampling: &SamplingConfig{
+			By:        SAMPLE_BY_TEMPLATE,
+			RateLimit: map[string]int{"error": 2},
+		},
+	})
+	h := LogHeader{}
+	for i := 0; i < 10; i++ {
+		l.Error(h, "err %d", i)
+		l.Info(h, "info %d", i)
+	}
+	l.Shutdown(context.Background())
+	errors := 0
+	for _, line := range mem.lines {
+		if strings.Contains(line, "[ERROR]") {
+			errors++
+		}
+	}
+	//a second boundary may let two more through
+	if errors < 2 || errors > 4 || len(mem.lines)-errors != 10 {
+		t.Errorf("unexpected lines %v", mem.lines)
+	}
+}
+
+func Test_samplingFullPath(t *testing.T) {
+	s := newSampler(&SamplingConfig{First: 1, Interval: 60})
+	if !s.allow(INFO, "/a/handler.go", 42, "") || !s.allow(INFO, "/b/handler.go", 42, "") {
+		t.Errorf("sites of different packages share a budget")
+	}
+	if s.allow(INFO, "/a/handler.go", 42, "") || s.allow(INFO, "/b/handler.go", 42, "") {
+		t.Errorf("expect the second entry of each site suppressed")
+	}
+	if m := s.take(); len(m) != 1 || m["handler.go:42"] != 2 {
+		t.Errorf("unexpected summary sites %v", m)
+	}
+}
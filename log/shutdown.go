@@ -0,0 +1,96 @@
+package log
+
+import (
+	"context"
+	"os"
+)
+
+// Sync flushes the entries queued on the default logger to its file.
+func Sync() error {
+	return Default().Sync()
+}
+
+// Shutdown flushes the default logger and stops its goroutines. It is
+// safe to call more than once and from a signal handler goroutine.
+func Shutdown(ctx context.Context) error {
+	return Default().Shutdown(ctx)
+}
+
+// send queues e, after shutdown it goes to stderr.
+func (l *BaseLogger) send(e *entry) {
+	l.state.RLock()
+	defer l.state.RUnlock()
+	if l.closed {
+		os.Stderr.Write(defaultLayout.format(e))
+		return
+	}
+	l.enqueue(e)
+}
+
+// Sync waits for the entries queued so far to be written and fsyncs
+// the log file.
+func (l *BaseLogger) Sync() error {
+	reply := make(chan error, 1)
+	select {
+	case l.syncChan <- reply:
+	case <-l.done:
+		return nil
+	}
+	return <-reply
+}
+
+// Shutdown drains logChan, syncs and closes the sinks and stops
+// writeLog and the cleanup goroutine. Entries logged afterwards are
+// written to stderr. If ctx expires first, ctx.Err() is returned and
+// the logger keeps shutting down in the background.
+func (l *BaseLogger) Shutdown(ctx context.Context) error {
+	l.closeOnce.Do(func() {
+		l.state.Lock()
+		l.closed = true
+		l.state.Unlock()
+		close(l.quit)
+	})
+	select {
+	case <-l.done:
+		return l.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain writes everything buffered in logChan, it runs on the
+// writeLog goroutine only.
+func (l *BaseLogger) drain() {
+	for {
+		select {
+		case e := <-l.logChan:
+			l.outPut(e)
+		default:
+			return
+		}
+	}
+}
+
+func (l *BaseLogger) syncSinks() error {
+	var err error
+	for _, s := range l.sinks {
+		if serr := s.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// stop is the last thing writeLog does.
+func (l *BaseLogger) stop() {
+	l.drain()
+	l.stopHooks()
+	err := l.syncSinks()
+	for _, s := range l.sinks {
+		if cerr := s.Close(); err == nil {
+			err = cerr
+		}
+	}
+	l.closeErr = err
+	close(l.done)
+}
@@ -0,0 +1,210 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink is a destination of log entries. Write receives one encoded
+// entry terminated by a newline, it is only called from the writer
+// goroutine of the logger.
+type Sink interface {
+	Write(level int, p []byte) error
+	Sync() error
+	Close() error
+}
+
+// LogConfig.Type
+const (
+	SINK_FILE   = "file"
+	SINK_STDOUT = "stdout"
+	SINK_STDERR = "stderr"
+	SINK_SYSLOG = "syslog"
+	SINK_UDP    = "udp"
+	SINK_TCP    = "tcp"
+)
+
+// sinkEntry is a Sink with its own level threshold and format. The
+// primary sink follows the runtime level of the logger instead.
+type sinkEntry struct {
+	Sink
+	level   int
+	mode    int
+	primary bool
+	//enc encodes LogObject, otherwise layout formats MOD_NORMAL text
+	enc    Encoder
+	layout *layout
+}
+
+// newSink creates the Sink described by c, an empty Type is a file.
+// A file sink is returned along with the error of opening its file.
+func newSink(c *LogConfig, onError func(err error)) (Sink, error) {
+	if c.Sink != nil {
+		return c.Sink, nil
+	}
+	switch c.Type {
+	case "", SINK_FILE:
+		return newFileSink(c, onError)
+	case SINK_STDOUT:
+		return &writerSink{w: os.Stdout}, nil
+	case SINK_STDERR:
+		return &writerSink{w: os.Stderr}, nil
+	case SINK_SYSLOG:
+		return newSyslogSink(c)
+	case SINK_UDP, SINK_TCP:
+		return newNetSink(c, onError), nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", c.Type)
+	}
+}
+
+// writerSink writes to an io.Writer it does not own, e.g. os.Stdout.
+type writerSink struct {
+	w io.Writer
+}
+
+func (s *writerSink) Write(level int, p []byte) error {
+	_, err := s.w.Write(p)
+	return err
+}
+
+func (s *writerSink) Sync() error {
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// timeouts of netSink, vars so that tests can shorten them
+var (
+	netDialTimeout  = 3 * time.Second
+	netWriteTimeout = 3 * time.Second
+	//redials back off from netDialTimeout up to netMaxBackoff
+	netMaxBackoff = 30 * time.Second
+)
+
+// netSink sends every entry to a udp or tcp collector. Entries are
+// queued and sent by a goroutine of the sink, so a slow or dead
+// collector never stalls writeLog and the other sinks. While the
+// collector cannot be reached, queued entries are dropped and redials
+// back off; a full queue drops new entries.
+type netSink struct {
+	network string
+	addr    string
+	queue   chan netItem
+	done    chan struct{}
+	onError func(err error)
+
+	//used by the send goroutine only
+	conn     net.Conn
+	backoff  time.Duration
+	nextDial time.Time
+
+	closeOnce sync.Once
+}
+
+// netItem is an entry, or a Sync waiting for the entries before it.
+type netItem struct {
+	p      []byte
+	synced chan struct{}
+}
+
+func newNetSink(c *LogConfig, onError func(err error)) *netSink {
+	size := c.BufferSize
+	if size <= 0 {
+		size = DEFAULT_BUFFER_SIZE
+	}
+	s := &netSink{
+		network: c.Type,
+		addr:    c.Addr,
+		queue:   make(chan netItem, size),
+		done:    make(chan struct{}),
+		onError: onError,
+	}
+	go s.run()
+	return s
+}
+
+func (s *netSink) Write(level int, p []byte) error {
+	select {
+	case s.queue <- netItem{p: append([]byte(nil), p...)}:
+		return nil
+	default:
+		return fmt.Errorf("logger %s sink %s: queue full, entry dropped", s.network, s.addr)
+	}
+}
+
+func (s *netSink) run() {
+	defer close(s.done)
+	for item := range s.queue {
+		if item.synced != nil {
+			close(item.synced)
+			continue
+		}
+		s.send(item.p)
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// send writes p, dialing first when there is no connection and the
+// backoff of the last failure has passed.
+func (s *netSink) send(p []byte) {
+	if s.conn == nil {
+		if time.Now().Before(s.nextDial) {
+			return
+		}
+		conn, err := net.DialTimeout(s.network, s.addr, netDialTimeout)
+		if err != nil {
+			s.fail(fmt.Errorf("logger %s sink dial %s: %w", s.network, s.addr, err))
+			return
+		}
+		s.conn, s.backoff = conn, 0
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(netWriteTimeout))
+	if _, err := s.conn.Write(p); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.fail(fmt.Errorf("logger %s sink write %s: %w", s.network, s.addr, err))
+	}
+}
+
+// fail reports err and delays the next dial.
+func (s *netSink) fail(err error) {
+	if s.backoff == 0 {
+		s.backoff = netDialTimeout
+	} else if s.backoff *= 2; s.backoff > netMaxBackoff {
+		s.backoff = netMaxBackoff
+	}
+	s.nextDial = time.Now().Add(s.backoff)
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// Sync waits until the entries queued before it were sent or dropped.
+func (s *netSink) Sync() error {
+	synced := make(chan struct{})
+	select {
+	case s.queue <- netItem{synced: synced}:
+	case <-s.done:
+		return nil
+	}
+	<-synced
+	return nil
+}
+
+// Close sends the queued entries and closes the connection.
+func (s *netSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.queue)
+	})
+	<-s.done
+	return nil
+}
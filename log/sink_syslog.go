@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+)
+
+// syslogSink forwards entries to syslog with a priority matching the
+// level. An empty Addr uses the local daemon, otherwise udp host:port.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(c *LogConfig) (Sink, error) {
+	network := ""
+	if c.Addr != "" {
+		network = "udp"
+	}
+	tag := c.Tag
+	if tag == "" {
+		tag = c.Name
+	}
+	w, err := syslog.Dial(network, c.Addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(level int, p []byte) error {
+	m := string(p)
+	switch level {
+	case DEBUG:
+		return s.w.Debug(m)
+	case WARN:
+		return s.w.Warning(m)
+	case ERROR:
+		return s.w.Err(m)
+	case PANIC, FATAL:
+		return s.w.Crit(m)
+	default:
+		return s.w.Info(m)
+	}
+}
+
+func (s *syslogSink) Sync() error {
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package log
+
+import (
+	"errors"
+)
+
+func newSyslogSink(c *LogConfig) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
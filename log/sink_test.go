@@ -0,0 +1,191 @@
+package log
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *memSink) Write(level int, p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, string(p))
+	return nil
+}
+
+func (s *memSink) Sync() error  { return nil }
+func (s *memSink) Close() error { return nil }
+
+func Test_sinks(t *testing.T) {
+	dir := t.TempDir()
+	mem := &memSink{}
+	l := newLogger(&LogConfig{
+		Path:  dir,
+		Name:  "sinks.json",
+		Mode:  MOD_JSON,
+		Level: INFO,
+		Sinks: []*LogConfig{
+			{Sink: mem, Mode: MOD_NORMAL, Level: ERROR},
+		},
+	})
+	h := LogHeader{LogId: "sinks"}
+	l.Debug(h, "dropped")
+	l.Info(h, "to file")
+	l.Error(h, "to both")
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed:%s", err.Error())
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "sinks.json"))
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("expect 2 lines in file, got %d", n)
+	}
+	if len(mem.lines) != 1 || !strings.Contains(mem.lines[0], "[ERROR] [sinks]") {
+		t.Errorf("unexpected mem sink lines %v", mem.lines)
+	}
+}
+
+type blockSink struct {
+	release chan struct{}
+}
+
+func (s *blockSink) Write(level int, p []byte) error {
+	<-s.release
+	return nil
+}
+
+func (s *blockSink) Sync() error  { return nil }
+func (s *blockSink) Close() error { return nil }
+
+func Test_overflowDropNewest(t *testing.T) {
+	s := &blockSink{release: make(chan struct{})}
+	l := newLogger(&LogConfig{Sink: s, BufferSize: 1, Overflow: OVERFLOW_DROP_NEWEST})
+	for i := 0; i < 10; i++ {
+		l.Info(LogHeader{}, "entry %d", i)
+	}
+	if n := l.Dropped(); n < 8 {
+		t.Errorf("expect at least 8 dropped entries, got %d", n)
+	}
+	close(s.release)
+	l.Shutdown(context.Background())
+}
+
+func Test_overflowSample(t *testing.T) {
+	s := &blockSink{release: make(chan struct{})}
+	l := newLogger(&LogConfig{Sink: s, BufferSize: 1, Overflow: OVERFLOW_SAMPLE, SampleRate: 2})
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			l.Info(LogHeader{}, "entry %d", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("OVERFLOW_SAMPLE blocked behind a stuck sink")
+	}
+	if n := l.Dropped(); n < 8 {
+		t.Errorf("expect at least 8 dropped entries, got %d", n)
+	}
+	close(s.release)
+	l.Shutdown(context.Background())
+}
+
+func Test_instances(t *testing.T) {
+	access := &memSink{}
+	biz := &memSink{}
+	a, _ := NewBaseLogger(&LogConfig{Sink: access})
+	b, _ := NewBaseLogger(&LogConfig{Sink: biz, Level: WARN})
+	a.Logger(LogHeader{LogId: "a"}).Info("access")
+	b.Logger(LogHeader{LogId: "b"}).Info("dropped")
+	b.Logger(LogHeader{LogId: "b"}).Warn("business")
+	a.Shutdown(context.Background())
+	b.Shutdown(context.Background())
+	if len(access.lines) != 1 || !strings.Contains(access.lines[0], "MSG:access") {
+		t.Errorf("unexpected access lines %v", access.lines)
+	}
+	if len(biz.lines) != 1 || !strings.Contains(biz.lines[0], "MSG:business") {
+		t.Errorf("unexpected business lines %v", biz.lines)
+	}
+}
+
+func Test_defaultWithoutInitialize(t *testing.T) {
+	Info(LogHeader{LogId: "default"}, "written to stderr")
+	if err := Sync(); err != nil {
+		t.Errorf("sync failed:%s", err.Error())
+	}
+}
+
+func Test_initializeKeepsSetDefault(t *testing.T) {
+	mem := &memSink{}
+	own := newLogger(&LogConfig{Sink: mem})
+	defer own.Shutdown(context.Background())
+	SetDefault(own)
+	Initialize_Base_Logger_with_config(&LogConfig{Type: SINK_STDERR})
+	defer Initialize_Base_Logger_with_config(&LogConfig{Type: SINK_STDERR})
+	if Default() == own {
+		t.Fatalf("default not replaced")
+	}
+	own.Info(LogHeader{LogId: "own"}, "still open")
+	own.Sync()
+	if len(mem.lines) != 1 {
+		t.Errorf("logger of SetDefault was shut down, lines %v", mem.lines)
+	}
+}
+
+func Test_netSinkStalled(t *testing.T) {
+	defer func(dial, write time.Duration) { netDialTimeout, netWriteTimeout = dial, write }(netDialTimeout, netWriteTimeout)
+	netDialTimeout, netWriteTimeout = 100*time.Millisecond, 100*time.Millisecond
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	//accepts and never reads
+	go func() {
+		var conns []net.Conn
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				for _, c := range conns {
+					c.Close()
+				}
+				return
+			}
+			conns = append(conns, c)
+		}
+	}()
+	mem := &memSink{}
+	var errs atomic.Int32
+	l := newLogger(&LogConfig{
+		Sink:    mem,
+		OnError: func(err error) { errs.Add(1) },
+		Sinks:   []*LogConfig{{Type: SINK_TCP, Addr: ln.Addr().String(), Mode: MOD_NORMAL}},
+	})
+	msg := strings.Repeat("x", 64<<10)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		l.Info(LogHeader{}, "%s", msg)
+	}
+	l.Shutdown(context.Background())
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("stalled collector blocked logging for %s", d)
+	}
+	if len(mem.lines) != 200 {
+		t.Errorf("expect 200 lines in the primary sink, got %d", len(mem.lines))
+	}
+	if errs.Load() == 0 {
+		t.Errorf("expect the stalled writes to be reported")
+	}
+}
@@ -0,0 +1,311 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slog levels of Panic and Fatal, below them slog.LevelError is ERROR,
+// slog.LevelWarn WARN, slog.LevelInfo INFO and lower levels DEBUG.
+const (
+	SlogLevelPanic = slog.LevelError + 4
+	SlogLevelFatal = slog.LevelError + 8
+)
+
+func fromSlogLevel(level slog.Level) int {
+	switch {
+	case level >= SlogLevelFatal:
+		return FATAL
+	case level >= SlogLevelPanic:
+		return PANIC
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	default:
+		return DEBUG
+	}
+}
+
+func toSlogLevel(level int) slog.Level {
+	switch level {
+	case FATAL:
+		return SlogLevelFatal
+	case PANIC:
+		return SlogLevelPanic
+	case ERROR:
+		return slog.LevelError
+	case WARN:
+		return slog.LevelWarn
+	case INFO:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// slogHandler is a slog.Handler writing to a logger.
+type slogHandler struct {
+	//nil writes to the default logger
+	base   *BaseLogger
+	h      LogHeader
+	groups string
+}
+
+// slogPayloadKey is the attribute carrying the msg of Tag, see FromSlog.
+const slogPayloadKey = "payload"
+
+// NewSlogHandler returns a slog.Handler writing to l with the header h,
+// a nil l writes to the default logger. When the context of a record
+// carries a Logger, see NewContext, its header is used instead of h.
+// Attributes become fields, those in groups are keyed "group.key", and
+// a top level "tag" attribute is the tag of the entry. For a record with
+// a tag and no message, a top level "payload" attribute is the msg.
+func NewSlogHandler(l *BaseLogger, h LogHeader) slog.Handler {
+	return &slogHandler{base: l, h: h}
+}
+
+func (s *slogHandler) logger() *BaseLogger {
+	if s.base != nil {
+		return s.base
+	}
+	return Default()
+}
+
+func (s *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	l := fromSlogLevel(level)
+	return l >= PANIC || s.logger().enabled(l, s.header(ctx).Module)
+}
+
+func (s *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := s.logger()
+	level := fromSlogLevel(r.Level)
+	header := s.header(ctx)
+	file, line := "???", 0
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file, line = f.File, f.Line
+	}
+	if level < PANIC && !(l.enabled(level, header.Module) && l.sample(level, file, line, r.Message)) {
+		return nil
+	}
+	var tag string
+	var msg interface{} = r.Message
+	var payload *slog.Attr
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "tag" && s.groups == "" && a.Value.Kind() == slog.KindString {
+			tag = a.Value.String()
+			return true
+		}
+		if a.Key == slogPayloadKey && s.groups == "" && r.Message == "" && payload == nil {
+			payload = &a
+			return true
+		}
+		fields = appendAttr(fields, s.groups, a)
+		return true
+	})
+	if payload != nil {
+		if tag != "" {
+			msg = payload.Value.Resolve().Any()
+		} else {
+			fields = appendAttr(fields, s.groups, *payload)
+		}
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	l.emitAt(t, level, header, file, line, tag, msg, fields...)
+	return nil
+}
+
+// header is h, or the header of the Logger carried by ctx with the
+// fields of h appended.
+func (s *slogHandler) header(ctx context.Context) LogHeader {
+	if ctx == nil {
+		return s.h
+	}
+	cl, ok := ctx.Value(contextKey{}).(Logger)
+	if !ok {
+		return s.h
+	}
+	header := cl.Head()
+	if len(s.h.Fields) > 0 {
+		header = withFields(header, s.h.Fields)
+	}
+	return header
+}
+
+func (s *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, s.groups, a)
+	}
+	c := *s
+	c.h = withFields(s.h, fields)
+	return &c
+}
+
+func (s *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return s
+	}
+	c := *s
+	c.groups = s.groups + name + "."
+	return &c
+}
+
+// appendAttr flattens a into fields, keys of groups are prefixed.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: slogValue(a.Value)})
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time()
+	}
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+	return v.Any()
+}
+
+// slogLogger is a Logger writing to a slog.Logger.
+type slogLogger struct {
+	s    *slog.Logger
+	h    LogHeader
+	skip int
+}
+
+// FromSlog returns a Logger writing to s with the header h. When s is
+// backed by NewSlogHandler, the entries carry h like those of any
+// other Logger, otherwise the logid and module are added as attributes.
+// Tag logs a record without message, with the "tag" and "payload"
+// attributes.
+func FromSlog(s *slog.Logger, h LogHeader) Logger {
+	return &slogLogger{s: s, h: h}
+}
+
+func (l *slogLogger) Logid() string {
+	return l.h.LogId
+}
+
+func (l *slogLogger) Head() LogHeader {
+	return l.h
+}
+
+// log hands a record to the handler of s, with the caller of the
+// method calling log as source.
+func (l *slogLogger) log(level int, tag, msg string, kv []interface{}) {
+	ctx := NewContext(context.Background(), l)
+	lv := toSlogLevel(level)
+	handler := l.s.Handler()
+	if !handler.Enabled(ctx, lv) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3+l.skip, pcs[:])
+	r := slog.NewRecord(time.Now(), lv, msg, pcs[0])
+	if _, ok := handler.(*slogHandler); !ok {
+		if l.h.LogId != "" {
+			r.AddAttrs(slog.String("logid", l.h.LogId))
+		}
+		if l.h.Module != "" {
+			r.AddAttrs(slog.String("module", l.h.Module))
+		}
+	}
+	if tag != "" {
+		r.AddAttrs(slog.String("tag", tag))
+	}
+	for _, f := range toFields(kv) {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	handler.Handle(ctx, r)
+}
+
+func (l *slogLogger) Debug(format string, v ...interface{}) {
+	l.log(DEBUG, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *slogLogger) Warn(format string, v ...interface{}) {
+	l.log(WARN, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *slogLogger) Error(format string, v ...interface{}) {
+	l.log(ERROR, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *slogLogger) Info(format string, v ...interface{}) {
+	l.log(INFO, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *slogLogger) Panic(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.log(PANIC, "", msg, nil)
+	if h, ok := l.s.Handler().(*slogHandler); ok {
+		h.logger().Sync()
+	}
+	panic(msg)
+}
+
+func (l *slogLogger) Fatal(format string, v ...interface{}) {
+	l.log(FATAL, "", fmt.Sprintf(format, v...), nil)
+	if h, ok := l.s.Handler().(*slogHandler); ok {
+		h.logger().Shutdown(context.Background())
+	}
+	exit(1)
+}
+
+func (l *slogLogger) Tag(tag string, msg interface{}) {
+	l.log(INFO, tag, "", []interface{}{Any(slogPayloadKey, msg)})
+}
+
+func (l *slogLogger) Debugw(msg string, kv ...interface{}) {
+	l.log(DEBUG, "", msg, kv)
+}
+
+func (l *slogLogger) Warnw(msg string, kv ...interface{}) {
+	l.log(WARN, "", msg, kv)
+}
+
+func (l *slogLogger) Errorw(msg string, kv ...interface{}) {
+	l.log(ERROR, "", msg, kv)
+}
+
+func (l *slogLogger) Infow(msg string, kv ...interface{}) {
+	l.log(INFO, "", msg, kv)
+}
+
+func (l *slogLogger) With(kv ...interface{}) Logger {
+	args := make([]interface{}, 0, len(kv))
+	for _, f := range toFields(kv) {
+		args = append(args, slog.Any(f.Key, f.Value))
+	}
+	return &slogLogger{s: l.s.With(args...), h: l.h, skip: l.skip}
+}
+
+func (l *slogLogger) WithCallerSkip(skip int) Logger {
+	return &slogLogger{s: l.s, h: l.h, skip: l.skip + skip}
+}
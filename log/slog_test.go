@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_slogHandler(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Mode: MOD_JSON, Level: INFO})
+	s := slog.New(NewSlogHandler(l, LogHeader{Product: "p"}))
+	s.Debug("filtered")
+	s.With("a", 1).WithGroup("g").Info("hello", "b", 2, "err", errors.New("boom"))
+	ctx := NewContext(context.Background(), l.Logger(LogHeader{LogId: "x", Module: "/m"}))
+	s.WarnContext(ctx, "from ctx", "tag", "t1")
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 2 {
+		t.Fatalf("expect 2 lines, got %v", mem.lines)
+	}
+	var o map[string]interface{}
+	json.Unmarshal([]byte(mem.lines[0]), &o)
+	trace, _ := o["trace"].(map[string]interface{})
+	if o["msg"] != "hello" || o["level"] != "INFO" || o["product"] != "p" || o["a"] != 1.0 ||
+		o["g.b"] != 2.0 || o["g.err"] != "boom" || trace["File"] != "slog_test.go" || trace["Line"] != 19.0 {
+		t.Errorf("unexpected entry %s", mem.lines[0])
+	}
+	o = nil
+	json.Unmarshal([]byte(mem.lines[1]), &o)
+	if o["logid"] != "x" || o["module"] != "/m" || o["tag"] != "t1" || o["level"] != "WARN" {
+		t.Errorf("unexpected entry %s", mem.lines[1])
+	}
+}
+
+func Test_fromSlog(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem})
+	FromSlog(slog.New(NewSlogHandler(l, LogHeader{})), LogHeader{LogId: "y"}).With("k", "v").Errorw("bad", "n", 1)
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 1 || !strings.Contains(mem.lines[0], "[slog_test.go:43][ERROR] [y][][] MSG:bad k=v n=1") {
+		t.Errorf("unexpected lines %v", mem.lines)
+	}
+
+	var b bytes.Buffer
+	text := slog.New(slog.NewTextHandler(&b, nil))
+	FromSlog(text, LogHeader{LogId: "z"}).Info("hi %d", 1)
+	if out := b.String(); !strings.Contains(out, `level=INFO msg="hi 1" logid=z`) {
+		t.Errorf("unexpected text %s", out)
+	}
+}
+
+func Test_slogTimeAndTag(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Mode: MOD_JSON})
+	h := NewSlogHandler(l, LogHeader{})
+	h.Handle(context.Background(), slog.NewRecord(time.Unix(1000, 0), slog.LevelInfo, "at", 0))
+	FromSlog(slog.New(h), LogHeader{}).Tag("order", map[string]int{"n": 1})
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 2 {
+		t.Fatalf("expect 2 lines, got %v", mem.lines)
+	}
+	var o map[string]interface{}
+	json.Unmarshal([]byte(mem.lines[0]), &o)
+	if o["timestamp"] != 1000.0 {
+		t.Errorf("record time not kept: %s", mem.lines[0])
+	}
+	o = nil
+	json.Unmarshal([]byte(mem.lines[1]), &o)
+	msg, _ := o["msg"].(map[string]interface{})
+	if o["tag"] != "order" || msg["n"] != 1.0 || o[slogPayloadKey] != nil {
+		t.Errorf("unexpected tag entry %s", mem.lines[1])
+	}
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const DEFAULT_STACK_DEPTH = 32
+
+// DEFAULT_STACK_FILTER are the function prefixes left out of stacks
+// when LogConfig.StackFilter is nil.
+var DEFAULT_STACK_FILTER = []string{"runtime.", "github.com/skadilover/easykit/"}
+
+// stackOf returns the stack above the caller of the logger method
+// calling it, or nil when stacks are off.
+func (l *BaseLogger) stackOf(header LogHeader) []string {
+	if !l.conf.StackTrace {
+		return nil
+	}
+	return l.formatStack(callerFrames(3 + l.conf.CallerSkip + header.skip))
+}
+
+// callerFrames returns the frames of the goroutine, skip is the one of
+// runtime.Callers as seen by the caller of callerFrames.
+func callerFrames(skip int) []runtime.Frame {
+	pcs := make([]uintptr, 128)
+	n := runtime.Callers(skip+1, pcs)
+	it := runtime.CallersFrames(pcs[:n])
+	var frames []runtime.Frame
+	for {
+		f, more := it.Next()
+		frames = append(frames, f)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// panicFrames drops the deferred calls and runtime frames above the
+// function that panicked, frames are returned as is outside a panic.
+func panicFrames(frames []runtime.Frame) []runtime.Frame {
+	for i, f := range frames {
+		if f.Function == "runtime.gopanic" {
+			for i++; i < len(frames) && strings.HasPrefix(frames[i].Function, "runtime."); i++ {
+			}
+			return frames[i:]
+		}
+	}
+	return frames
+}
+
+// formatStack renders frames as "function file:line", filtered frames
+// do not count against StackDepth.
+func (l *BaseLogger) formatStack(frames []runtime.Frame) []string {
+	depth := l.conf.StackDepth
+	if depth <= 0 {
+		depth = DEFAULT_STACK_DEPTH
+	}
+	filter := l.conf.StackFilter
+	if filter == nil {
+		filter = DEFAULT_STACK_FILTER
+	}
+	stack := make([]string, 0, depth)
+	for _, f := range frames {
+		if len(stack) == depth {
+			break
+		}
+		if !skipFrame(f.Function, filter) {
+			stack = append(stack, f.Function+" "+f.File+":"+strconv.Itoa(f.Line))
+		}
+	}
+	return stack
+}
+
+func skipFrame(function string, filter []string) bool {
+	for _, prefix := range filter {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasError reports whether one of v is a non nil error, or an Err field
+// in key/value pairs.
+func hasError(v []interface{}) bool {
+	for _, a := range v {
+		switch a := a.(type) {
+		case error:
+			return true
+		case Field:
+			if a.Key == "error" && a.Value != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Recovered logs v, the value of a recovered panic, at ERROR on l.
+// Called from the deferred function, File/Line and the stack are the
+// ones of the panic.
+func Recovered(l Logger, v interface{}) {
+	if r, ok := AddCallerSkip(l, 1).(interface {
+		Recovered(v interface{})
+	}); ok {
+		r.Recovered(v)
+		return
+	}
+	l.Error("panic: %v", v)
+}
+
+// Recovered logs v with header, see the package level Recovered.
+func (l *BaseLogger) Recovered(header LogHeader, v interface{}) {
+	if !l.enabled(ERROR, header.Module) {
+		return
+	}
+	frames := panicFrames(callerFrames(2 + l.conf.CallerSkip + header.skip))
+	file, line := "???", 0
+	if len(frames) > 0 {
+		file, line = frames[0].File, frames[0].Line
+	}
+	if l.conf.StackTrace {
+		header.stack = l.formatStack(frames)
+	}
+	l.emit(ERROR, header, file, line, "", fmt.Sprintf("panic: %v", v))
+}
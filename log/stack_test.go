@@ -0,0 +1,78 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func failing() {
+	var m map[string]int
+	m["x"] = 1
+}
+
+func Test_stack(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{
+		Sink:        mem,
+		Mode:        MOD_JSON,
+		StackTrace:  true,
+		StackDepth:  2,
+		StackFilter: []string{"runtime.", "testing."},
+	})
+	h := LogHeader{LogId: "stack"}
+	l.Error(h, "no error")
+	l.Error(h, "failed: %v", errors.New("boom"))
+	l.Logger(h).Errorw("failed", Err(errors.New("boom")))
+	func() {
+		defer func() {
+			Recovered(l.Logger(h), recover())
+		}()
+		failing()
+	}()
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 4 {
+		t.Fatalf("expect 4 lines, got %v", mem.lines)
+	}
+	for i, line := range mem.lines {
+		var o struct {
+			Msg   string
+			Trace struct {
+				Line  int
+				Stack []string
+			}
+		}
+		if err := json.Unmarshal([]byte(line), &o); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if o.Trace.Stack != nil {
+				t.Errorf("unexpected stack %v", o.Trace.Stack)
+			}
+			continue
+		}
+		//the goroutine has no frames left past testing.tRunner
+		want, wantLen := "log.Test_stack ", 1
+		if i == 3 {
+			want, wantLen = "log.failing ", 2
+			if o.Msg != "panic: assignment to entry in nil map" || o.Trace.Line != 13 {
+				t.Errorf("unexpected panic entry %s", line)
+			}
+		}
+		if len(o.Trace.Stack) != wantLen || !strings.Contains(o.Trace.Stack[0], want) {
+			t.Errorf("unexpected stack of %d: %v", i, o.Trace.Stack)
+		}
+	}
+}
+
+func Test_stackText(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, StackTrace: true, StackFilter: []string{"runtime.", "testing."}})
+	l.Error(LogHeader{LogId: "stack"}, "failed: %v", errors.New("boom"))
+	l.Shutdown(context.Background())
+	if len(mem.lines) != 1 || !strings.Contains(mem.lines[0], "MSG:failed: boom\n\t") || !strings.Contains(mem.lines[0], "log.Test_stackText ") {
+		t.Errorf("no stack in %v", mem.lines)
+	}
+}
@@ -0,0 +1,211 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestEntry is an entry recorded by a TestLogger, Fields include the
+// ones added by With.
+type TestEntry struct {
+	Level  int
+	Msg    string
+	Tag    string
+	Fields []Field
+}
+
+// String formats e like "[ERROR] msg key=value".
+func (e TestEntry) String() string {
+	var b strings.Builder
+	b.WriteString("[" + levelStr[e.Level] + "] ")
+	if e.Tag != "" {
+		b.WriteString(e.Tag + " ")
+	}
+	b.WriteString(e.Msg)
+	appendText(&b, e.Fields)
+	return b.String()
+}
+
+// TestLogger is a Logger for unit tests. It records the entries for
+// assertions and forwards them to t.Logf, Error and Errorw fail the
+// test unless NoFailOnError is set.
+type TestLogger struct {
+	t testing.TB
+	h LogHeader
+	//shared with the loggers returned by With
+	rec *testRecord
+}
+
+type testRecord struct {
+	mu            sync.Mutex
+	entries       []TestEntry
+	noFailOnError bool
+}
+
+// GetTestLogger returns a TestLogger failing t on Error.
+func GetTestLogger(t *testing.T) Logger {
+	return NewTestLogger(t)
+}
+
+func NewTestLogger(t testing.TB) *TestLogger {
+	return &TestLogger{t: t, rec: &testRecord{}}
+}
+
+// NoFailOnError only records Error and Errorw entries, so that error
+// paths can be tested with AssertLogged.
+func (l *TestLogger) NoFailOnError() *TestLogger {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	l.rec.noFailOnError = true
+	return l
+}
+
+// Entries returns a copy of the entries recorded so far.
+func (l *TestLogger) Entries() []TestEntry {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	return append([]TestEntry(nil), l.rec.entries...)
+}
+
+// Reset forgets the recorded entries.
+func (l *TestLogger) Reset() {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	l.rec.entries = nil
+}
+
+// Logged reports whether an entry of level contains substr in its
+// String form.
+func (l *TestLogger) Logged(level int, substr string) bool {
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.String(), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails the test when no entry of level contains substr.
+func (l *TestLogger) AssertLogged(level int, substr string) {
+	l.t.Helper()
+	if !l.Logged(level, substr) {
+		l.t.Errorf("no %s entry contains %q, logged:\n%s", levelStr[level], substr, l.dump())
+	}
+}
+
+// AssertNotLogged fails the test when an entry of level contains substr.
+func (l *TestLogger) AssertNotLogged(level int, substr string) {
+	l.t.Helper()
+	if l.Logged(level, substr) {
+		l.t.Errorf("unexpected %s entry containing %q, logged:\n%s", levelStr[level], substr, l.dump())
+	}
+}
+
+func (l *TestLogger) dump() string {
+	var b strings.Builder
+	for _, e := range l.Entries() {
+		b.WriteString("\t" + e.String() + "\n")
+	}
+	return b.String()
+}
+
+// record stores an entry and forwards it to t.
+func (l *TestLogger) record(level int, tag, msg string, kv []interface{}) {
+	e := TestEntry{
+		Level:  level,
+		Msg:    msg,
+		Tag:    tag,
+		Fields: withFields(l.h, toFields(kv)).Fields,
+	}
+	l.rec.mu.Lock()
+	l.rec.entries = append(l.rec.entries, e)
+	fail := level == ERROR && !l.rec.noFailOnError
+	l.rec.mu.Unlock()
+	l.t.Helper()
+	if fail {
+		l.t.Errorf("%s", e)
+	} else {
+		l.t.Logf("%s", e)
+	}
+}
+
+func (l *TestLogger) Logid() string {
+	if l.h.LogId == "" {
+		return "test"
+	}
+	return l.h.LogId
+}
+
+func (l *TestLogger) Head() LogHeader {
+	return l.h
+}
+
+func (l *TestLogger) Debug(format string, v ...interface{}) {
+	l.t.Helper()
+	l.record(DEBUG, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *TestLogger) Warn(format string, v ...interface{}) {
+	l.t.Helper()
+	l.record(WARN, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *TestLogger) Error(format string, v ...interface{}) {
+	l.t.Helper()
+	l.record(ERROR, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *TestLogger) Info(format string, v ...interface{}) {
+	l.t.Helper()
+	l.record(INFO, "", fmt.Sprintf(format, v...), nil)
+}
+
+func (l *TestLogger) Panic(format string, v ...interface{}) {
+	l.t.Helper()
+	msg := fmt.Sprintf(format, v...)
+	l.record(PANIC, "", msg, nil)
+	panic(msg)
+}
+
+func (l *TestLogger) Fatal(format string, v ...interface{}) {
+	l.t.Helper()
+	l.record(FATAL, "", fmt.Sprintf(format, v...), nil)
+	l.t.FailNow()
+}
+
+func (l *TestLogger) Tag(tag string, msg interface{}) {
+	l.t.Helper()
+	l.record(INFO, tag, fmt.Sprint(msg), nil)
+}
+
+func (l *TestLogger) Debugw(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.record(DEBUG, "", msg, kv)
+}
+
+func (l *TestLogger) Warnw(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.record(WARN, "", msg, kv)
+}
+
+func (l *TestLogger) Errorw(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.record(ERROR, "", msg, kv)
+}
+
+func (l *TestLogger) Infow(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.record(INFO, "", msg, kv)
+}
+
+func (l *TestLogger) With(kv ...interface{}) Logger {
+	return &TestLogger{t: l.t, h: withFields(l.h, toFields(kv)), rec: l.rec}
+}
+
+// WithHeader returns a TestLogger recording to l with the header h,
+// e.g. to test code reading Logid.
+func (l *TestLogger) WithHeader(h LogHeader) *TestLogger {
+	return &TestLogger{t: l.t, h: h, rec: l.rec}
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeT records the failures of a TestLogger instead of failing.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Logf(format string, args ...interface{}) {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_testLogger(t *testing.T) {
+	ft := &fakeT{TB: t}
+	l := NewTestLogger(ft)
+	l.Info("hello %s", "world")
+	l.With("user", 7).Errorw("failed", "code", 500)
+	l.Tag("audit", map[string]int{"n": 1})
+	if len(ft.errors) != 1 || ft.errors[0] != "[ERROR] failed user=7 code=500" {
+		t.Errorf("expect the error to fail the test, got %v", ft.errors)
+	}
+	l.AssertLogged(INFO, "hello world")
+	l.AssertLogged(ERROR, "code=500")
+	l.AssertLogged(INFO, "audit map[n:1]")
+	l.AssertNotLogged(WARN, "hello")
+	if n := len(l.Entries()); n != 3 {
+		t.Errorf("expect 3 entries, got %d", n)
+	}
+
+	ft.errors = nil
+	l.Reset()
+	l.NoFailOnError().Error("ignored")
+	l.AssertLogged(DEBUG, "ignored")
+	if len(ft.errors) != 1 || len(l.Entries()) != 1 {
+		t.Errorf("expect only the failed assertion, got %v", ft.errors)
+	}
+	if l.Logid() != "test" || l.WithHeader(LogHeader{LogId: "x"}).Logid() != "x" {
+		t.Errorf("unexpected logid")
+	}
+}
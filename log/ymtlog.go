@@ -5,14 +5,14 @@
 package log
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,25 +21,109 @@ type LogConfig struct {
 	Name  string
 	Mode  int
 	Level int
+
+	//SINK_FILE(default), SINK_STDOUT, SINK_STDERR, SINK_SYSLOG, SINK_UDP or SINK_TCP
+	Type string
+	//host:port of SINK_UDP, SINK_TCP and remote SINK_SYSLOG
+	Addr string
+	//syslog tag, defaults to Name
+	Tag string
+	//custom destination, overrides Type
+	Sink Sink `toml:"-"`
+	//more destinations, each with its own Level and Mode
+	Sinks []*LogConfig
+
+	//timestamp of LogObject encodings: TS_SECOND(default), TS_MILLI,
+	//TS_NANO or TS_RFC3339NANO
+	TimestampFormat int
+	//custom encoding of LogObject, overrides Mode
+	Encoder Encoder `toml:"-"`
+	//MOD_NORMAL line layout, see DEFAULT_PATTERN
+	Pattern string
+	//time layout of %time, defaults to DEFAULT_TIME_FORMAT
+	TimeFormat string
+	//time zone of %time such as "UTC" or "Asia/Shanghai", defaults to local
+	TimeZone string
+
+	//sensitive keys of bodies, fields and structured messages such as
+	//those of Tag and InfoJson, plain string messages are not redacted,
+	//see RedactRule
+	Redact []*RedactRule
+	//secret of REDACT_HASH rules without a HashKey, required for hashed
+	//values not to be reversible by trying every candidate
+	RedactHashKey string
+	//stack of ERROR entries logged with an error argument and of
+	//panics passed to Recovered, written to Trace["Stack"]
+	StackTrace bool
+	//max frames of a stack, defaults to DEFAULT_STACK_DEPTH
+	StackDepth int
+	//function prefixes of frames left out of stacks, nil uses
+	//DEFAULT_STACK_FILTER and an empty list keeps every frame
+	StackFilter []string
+
+	//max bytes of a logged body, 0 logs the whole body, see Body
+	MaxBodySize int
+	//content type prefixes whose bodies are not logged,
+	//nil uses DEFAULT_SKIP_CONTENT_TYPES
+	SkipContentTypes []string
+
+	//per call site sampling and per level rate limits, nil logs everything
+	Sampling *SamplingConfig
+
+	//called when logging itself fails, e.g. the file cannot be opened
+	//or rotated, instead of printing to stderr. It may be called from
+	//different goroutines, see Errors.
+	OnError func(err error) `toml:"-"`
+
+	//frames to skip when the package is wrapped, so that File/Line
+	//report the caller of the wrapper
+	CallerSkip int
+
+	//entries buffered for the writer goroutine, and for the sender of
+	//SINK_UDP and SINK_TCP sinks, defaults to 1024
+	BufferSize int
+	//OVERFLOW_BLOCK(default), OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST or OVERFLOW_SAMPLE
+	Overflow int
+	//OVERFLOW_SAMPLE keeps one of every SampleRate entries while full,
+	//dropping the oldest one for it
+	SampleRate int
+
+	//ROTATE_DAILY(default), ROTATE_HOURLY, ROTATE_SIZE, ROTATE_INTERVAL
+	//or ROTATE_EXTERNAL
+	Rotate int
+	//max bytes of the current file, used by ROTATE_SIZE
+	MaxSize int64
+	//seconds between two rotations, used by ROTATE_INTERVAL
+	RotateInterval int64
+	//reopen the files on SIGHUP, see Reopen
+	ReopenOnSignal bool
+
+	//max number of rotated files to keep, 0 keeps all
+	MaxBackups int
+	//max days to keep rotated files, 0 keeps all
+	MaxAge int
+	//gzip rotated files
+	Compress bool
 }
 
 var logConfig *LogConfig
 
-func Initialize_Base_Logger(path, name string, mode, level int) {
-	logConfig = &LogConfig{
+// Initialize_Base_Logger replaces the default logger. It is installed
+// even when an error is returned, see NewBaseLogger.
+func Initialize_Base_Logger(path, name string, mode, level int) error {
+	return Initialize_Base_Logger_with_config(&LogConfig{
 		Path:  path,
 		Name:  name,
 		Mode:  mode,
 		Level: level,
-	}
-	_log = NewLogger(logConfig.Path, logConfig.Name)
-	_log.logLevel = logConfig.Level
+	})
 }
 
-func Initialize_Base_Logger_with_config(c *LogConfig) {
+func Initialize_Base_Logger_with_config(c *LogConfig) error {
 	logConfig = c
-	_log = NewLogger(logConfig.Path, logConfig.Name)
-	_log.logLevel = logConfig.Level
+	l, err := NewBaseLogger(c)
+	replaceDefault(l)
+	return err
 }
 
 /*
@@ -48,25 +132,79 @@ func Initialize_Base_Logger_with_config(c *LogConfig) {
  */
 const (
 	DATEFORMAT        = "2006-01-02"
-	DEFAULT_LOG_SCAN  = 300
+	HOURFORMAT        = "2006-01-02-15"
 	DEFAULT_LOG_LEVEL = DEBUG
 )
 
+/*
+*PANIC级别写完日志并刷盘后panic,FATAL级别写完日志并关闭logger后os.Exit(1)
+*二者不受日志级别过滤的影响
+ */
 const (
 	DEBUG = iota
 	INFO
 	WARN
 	ERROR
+	PANIC
+	FATAL
 )
 const (
 	Info_str  = "INFO"
 	Error_str = "ERROR"
 	Debug_str = "DEBUG"
 	Warn_str  = "WARN"
+	Panic_str = "PANIC"
+	Fatal_str = "FATAL"
 )
+
+var levelStr = map[int]string{
+	DEBUG: Debug_str,
+	INFO:  Info_str,
+	WARN:  Warn_str,
+	ERROR: Error_str,
+	PANIC: Panic_str,
+	FATAL: Fatal_str,
+}
+
 const (
 	MOD_NORMAL = iota
 	MOD_JSON
+	MOD_LOGFMT
+	//length prefixed records, see binary.go
+	MOD_BINARY
+)
+
+/*
+*日志缓冲区满时的处理策略,丢弃的条数可通过Dropped()获取
+ */
+const (
+	OVERFLOW_BLOCK = iota
+	OVERFLOW_DROP_NEWEST
+	OVERFLOW_DROP_OLDEST
+	OVERFLOW_SAMPLE
+)
+
+const DEFAULT_BUFFER_SIZE = 1024
+
+const (
+	TS_SECOND = iota
+	TS_MILLI
+	TS_NANO
+	TS_RFC3339NANO
+)
+
+/*
+*ROTATE_DAILY和ROTATE_SIZE的备份文件名为name.YYYY-MM-DD[_N]
+*ROTATE_HOURLY和ROTATE_INTERVAL的备份文件名为name.YYYY-MM-DD-HH[_N]
+*ROTATE_SIZE在跨天时同样会切分文件
+*ROTATE_EXTERNAL不切分文件,交给logrotate等外部工具,文件被移走或删除后自动重新打开
+ */
+const (
+	ROTATE_DAILY = iota
+	ROTATE_HOURLY
+	ROTATE_SIZE
+	ROTATE_INTERVAL
+	ROTATE_EXTERNAL
 )
 
 type LogObject struct {
@@ -80,6 +218,10 @@ type LogObject struct {
 	Msg       interface{}            `json:"msg"`
 	Trace     map[string]interface{} `json:"trace"`
 	Tag       string                 `json:"tag"`
+	//written as top level keys, see MarshalJSON
+	Fields []Field `json:"-"`
+	//call site time, encoders render it according to TimestampFormat
+	Time time.Time `json:"-"`
 }
 
 type LogHeader struct {
@@ -92,360 +234,492 @@ type LogHeader struct {
 	Module   string
 	Lat      string
 	Lng      string
+	//inherited by every entry logged with this header
+	Fields []Field
+	//frames of wrappers between the user and the logger, see AddCallerSkip
+	skip int
+	//stack of the entry, see stack.go
+	stack []string
 }
 
-var _log *logger = nil
+// _log is behind the package level functions, it writes to stderr
+// until Initialize_Base_Logger is called.
+var _log atomic.Pointer[BaseLogger]
 
-type logger struct {
-	mu       *sync.RWMutex
-	fileDir  string
-	fileName string
+// _owned is the default logger created by this package, the one
+// Initialize_Base_Logger shuts down when replacing it.
+var _owned atomic.Pointer[BaseLogger]
 
-	date time.Time
+func init() {
+	replaceDefault(newLogger(&LogConfig{Type: SINK_STDERR, Level: DEFAULT_LOG_LEVEL}))
+}
 
-	logFile  *os.File
-	lger     *log.Logger
-	timeScan int64
+// Default returns the logger used by the package level functions.
+func Default() *BaseLogger {
+	return _log.Load()
+}
 
-	logChan  chan string
-	objChan  chan *LogObject
-	logLevel int
+// SetDefault makes l the logger of the package level functions, the
+// previous one is left open for its owner.
+func SetDefault(l *BaseLogger) {
+	_log.Store(l)
 }
 
-func NewLogger(dir string, name string) *logger {
-	dailyLogger := &logger{
-		mu:       new(sync.RWMutex),
-		fileDir:  dir,
-		fileName: name,
-		logChan:  make(chan string, 1024),
-		objChan:  make(chan *LogObject, 1024),
-		logLevel: DEFAULT_LOG_LEVEL,
+// replaceDefault installs l, created by this package, and shuts down
+// the logger it created before. A logger installed with SetDefault is
+// left to its owner.
+func replaceDefault(l *BaseLogger) {
+	_log.Store(l)
+	if old := _owned.Swap(l); old != nil {
+		old.Shutdown(context.Background())
 	}
+}
 
-	dailyLogger.initDailyLogger()
+// NewBaseLogger creates an independent logger from c. The logger is
+// returned even with an error, which joins the errors of its sinks: a
+// file that cannot be opened is written to stderr until it can, other
+// failing sinks are left out.
+func NewBaseLogger(c *LogConfig) (*BaseLogger, error) {
+	return openLogger(c)
+}
 
-	return dailyLogger
+// BaseLogger is a logger instance with its own sinks, levels and
+// writer goroutine, e.g. an access log next to the business log.
+type BaseLogger struct {
+	//primary file, nil when the primary sink is not a file
+	*fileSink
+	conf     *LogConfig
+	sinks    []*sinkEntry
+	redactor *Redactor
+	sampler  *sampler
+
+	hookMu   sync.Mutex
+	hooks    atomic.Pointer[[]*hookEntry]
+	hookChan chan hookCall
+	hookDone chan struct{}
+
+	logChan chan *entry
+	//level of the primary sink, see level.go
+	logLevel atomic.Int32
+	modules  atomic.Pointer[map[string]int]
+	//lowest level of the other sinks
+	sinkLevel int
+
+	//entries dropped by the overflow policy
+	dropped atomic.Uint64
+	//hook calls dropped because the hooks fell behind
+	hookDropped atomic.Uint64
+	//sends that found logChan full, drives OVERFLOW_SAMPLE
+	overflowed atomic.Uint64
+	//failures of the logger itself, see Errors
+	errCount atomic.Uint64
+
+	state     sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+	syncChan  chan chan error
+	quit      chan struct{}
+	done      chan struct{}
 }
 
-func (l *logger) initDailyLogger() {
-	l.date, _ = time.Parse(DATEFORMAT, time.Now().Format(DATEFORMAT))
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// entry is a log record queued for the writer goroutine, it is
+// encoded there by every sink.
+type entry struct {
+	level int
+	//passed the level of the primary sink
+	primary bool
+	time    time.Time
+	header  LogHeader
+	file    string
+	line    int
+	tag     string
+	msg     interface{}
+	fields  []Field
+}
 
-	logFile := joinFilePath(l.fileDir, l.fileName)
-	l.logFile, _ = os.OpenFile(logFile, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
-	if logConfig.Mode == MOD_JSON {
-		l.lger = log.New(l.logFile, "", 0)
-	} else {
-		l.lger = log.New(l.logFile, "", log.LstdFlags|log.Lmicroseconds)
+func NewLogger(dir string, name string) *BaseLogger {
+	c := &LogConfig{
+		Path:  dir,
+		Name:  name,
+		Level: DEFAULT_LOG_LEVEL,
+	}
+	if logConfig != nil {
+		c.Mode = logConfig.Mode
 	}
+	return newLogger(c)
+}
 
-	go l.writeLog()
-	go l.monitorFile()
+// newLogger is NewBaseLogger for loggers whose errors are only
+// reported, see reportError.
+func newLogger(c *LogConfig) *BaseLogger {
+	l, _ := openLogger(c)
+	return l
 }
 
-func (l *logger) isNeedRotate() bool {
-	t, _ := time.Parse(DATEFORMAT, time.Now().Format(DATEFORMAT))
-	if t.After(l.date) {
-		return true
+func openLogger(c *LogConfig) (*BaseLogger, error) {
+	size := c.BufferSize
+	if size <= 0 {
+		size = DEFAULT_BUFFER_SIZE
+	}
+	l := &BaseLogger{
+		conf:     c,
+		redactor: newRedactor(c),
+		sampler:  newSampler(c.Sampling),
+		logChan:  make(chan *entry, size),
+		syncChan: make(chan chan error),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		//only PANIC and FATAL pass when there is no other sink
+		sinkLevel: PANIC,
 	}
-	return false
-}
-
-func (l *logger) rotate() {
-	logFile := joinFilePath(l.fileDir, l.fileName)
-	originBakName := logFile + "." + l.date.Format(DATEFORMAT)
-	logFileBak := originBakName
-	for i := 0; ; i++ {
-		if isExist(logFileBak) {
-			logFileBak = originBakName + "_" + strconv.Itoa(i)
-		} else {
-			break
+	l.logLevel.Store(int32(c.Level))
+	var errs []error
+	for _, sc := range append([]*LogConfig{c}, c.Sinks...) {
+		if sc != c && sc.Level < l.sinkLevel {
+			l.sinkLevel = sc.Level
 		}
+		s, err := newSink(sc, l.reportError)
+		if err != nil {
+			l.reportError(err)
+			errs = append(errs, err)
+			if s == nil && sc != c {
+				continue
+			}
+			if s == nil {
+				s = &writerSink{w: os.Stderr}
+			}
+		}
+		if f, ok := s.(*fileSink); ok && sc == c {
+			l.fileSink = f
+		}
+		se := &sinkEntry{Sink: s, level: sc.Level, mode: sc.Mode, primary: sc == c}
+		if se.enc = newEncoder(sc); se.enc == nil {
+			if se.layout, err = newLayout(sc); err != nil {
+				l.reportError(err)
+				errs = append(errs, err)
+			}
+		}
+		l.sinks = append(l.sinks, se)
 	}
-	if l.logFile != nil {
-		l.logFile.Close()
+
+	go l.writeLog()
+	if l.sampler != nil {
+		go l.summaryLoop()
 	}
-	err := os.Rename(logFile, logFileBak)
-	if err != nil {
-		l.lger.Printf("logger rename error: %v", err.Error())
+	if c.ReopenOnSignal {
+		l.watchSignal()
 	}
 
-	l.logFile, _ = os.Create(logFile)
-	if logConfig.Mode == MOD_JSON {
-		l.lger = log.New(l.logFile, "", 0)
-	} else {
-		l.lger = log.New(l.logFile, "", log.LstdFlags|log.Lmicroseconds)
-	}
+	return l, errors.Join(errs...)
+}
+
+// passive to close filelogger
+func (l *BaseLogger) Close() error {
+	return l.Shutdown(context.Background())
 }
 
-func (l *logger) monitorFile() {
+// Receive logStr from f's logChan and print logstr to file
+func (f *BaseLogger) writeLog() {
 	defer func() {
 		if err := recover(); err != nil {
-			l.lger.Panic("logger's FileMonitor() catch panic: %v\n", err)
+			f.reportError(fmt.Errorf("logger writeLog catch panic: %v", err))
 		}
 	}()
 
-	// check frequency
-	logScan := DEFAULT_LOG_SCAN
-
-	timer := time.NewTicker(time.Duration(logScan) * time.Second)
-	defer timer.Stop()
 	for {
 		select {
-		case <-timer.C:
-			l.checkFile()
+		case e := <-f.logChan:
+			f.outPut(e)
+		case reply := <-f.syncChan:
+			f.drain()
+			reply <- f.syncSinks()
+		case <-f.quit:
+			f.stop()
+			return
 		}
 	}
 }
 
-func (l *logger) checkFile() {
-	if l.isNeedRotate() {
-		l.mu.Lock()
-		l.rotate()
-		l.mu.Unlock()
-		l.date, _ = time.Parse(DATEFORMAT, time.Now().Format(DATEFORMAT))
-	}
-}
-
-// passive to close filelogger
-func (l *logger) Close() error {
-
-	close(l.logChan)
-	l.lger = nil
-
-	return l.logFile.Close()
-}
-
-// Receive logStr from f's logChan and print logstr to file
-func (f *logger) writeLog() {
+// outPut encodes e once per encoder or layout and fans it out to
+// the sinks. A panic of a sink or of a value being encoded only loses
+// e, writeLog keeps serving Sync, Shutdown and the entries queued.
+func (l *BaseLogger) outPut(e *entry) {
 	defer func() {
 		if err := recover(); err != nil {
-			log.Printf(" writeLog catch panic: %v\n", err)
+			l.reportError(fmt.Errorf("logger output catch panic: %v", err))
 		}
 	}()
-
-	for {
-		select {
-		case str := <-f.logChan:
-			f.outPut(str)
-		case obj := <-f.objChan:
-			f.outPutObj(obj)
+	if l.redactor != nil {
+		e.fields = l.redactor.Fields(e.fields)
+		e.msg = l.redactor.Value(e.msg)
+	}
+	var obj *LogObject
+	lines := make(map[interface{}][]byte, 1)
+	for _, s := range l.sinks {
+		if s.primary && !e.primary || !s.primary && e.level < s.level {
+			continue
+		}
+		var key interface{} = s.layout
+		if s.enc != nil {
+			key = s.enc
+		}
+		line, ok := lines[key]
+		if !ok {
+			if s.enc == nil {
+				line = s.layout.format(e)
+			} else {
+				if obj == nil {
+					obj = e.object()
+				}
+				var err error
+				if line, err = s.enc.Encode(obj); err != nil {
+					l.reportError(fmt.Errorf("logger encode: %w", err))
+				}
+			}
+			lines[key] = line
+		}
+		if line == nil {
+			continue
+		}
+		if err := s.Write(e.level, line); err != nil {
+			l.reportError(fmt.Errorf("logger write: %w", err))
 		}
 	}
+	if l.hooked(e.level) {
+		if obj == nil {
+			obj = e.object()
+		}
+		l.fire(obj, e.level)
+	}
+}
+
+func (e *entry) object() *LogObject {
+	o := &LogObject{
+		Timestamp: e.time.Unix(),
+		Time:      e.time,
+		Level:     levelStr[e.level],
+		Logid:     e.header.LogId,
+		Product:   e.header.Product,
+		Module:    e.header.Module,
+		Caller_ip: e.header.CallerIp,
+		Host_ip:   e.header.HostIp,
+		Msg:       e.msg,
+		Trace:     make(map[string]interface{}),
+		Tag:       e.tag,
+		Fields:    e.fields,
+	}
+	o.Trace["File"] = shortFileName(e.file)
+	o.Trace["Line"] = e.line
+	if e.header.stack != nil {
+		o.Trace["Stack"] = e.header.stack
+	}
+	return o
 }
 
-// print log
-func (l *logger) outPut(str string) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+// emit queues an entry, extra are appended to the fields of the header.
+func (l *BaseLogger) emit(level int, header LogHeader, file string, line int, tag string, msg interface{}, extra ...Field) {
+	l.emitAt(time.Now(), level, header, file, line, tag, msg, extra...)
+}
 
-	l.lger.Output(2, str)
+// emitAt is emit for an entry logged at t, such as a slog record.
+func (l *BaseLogger) emitAt(t time.Time, level int, header LogHeader, file string, line int, tag string, msg interface{}, extra ...Field) {
+	fields := header.Fields
+	if len(extra) > 0 {
+		fields = withFields(header, extra).Fields
+	}
+	l.send(&entry{
+		level:   level,
+		primary: level >= l.levelFor(header.Module),
+		time:    t,
+		header:  header,
+		file:    file,
+		line:    line,
+		tag:     tag,
+		msg:     msg,
+		fields:  fields,
+	})
 }
 
-func (l *logger) outPutObj(o *LogObject) {
-	d, err := json.Marshal(o)
-	if err != nil {
-		fmt.Println("Output unmarshal error.", err)
+// caller returns the call site of the logger method calling it. Every
+// wrapper in between, like the package level functions, adds one to
+// header.skip, LogConfig.CallerSkip covers wrappers outside easykit.
+func (l *BaseLogger) caller(header LogHeader) (string, int) {
+	_, file, line, ok := runtime.Caller(2 + l.conf.CallerSkip + header.skip)
+	if !ok {
+		return "???", 0
 	}
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	l.lger.Printf("%s", d)
+	return file, line
 }
 
-//Tag log
+// Tag log
 func Tag(header LogHeader, tag string, msg interface{}) {
-	_log.Tag(header, tag, msg)
+	header.skip++
+	Default().Tag(header, tag, msg)
 }
 
-//info log
+// info log
 func Info(header LogHeader, format string, v ...interface{}) {
-	_log.Info(header, format, v...)
+	header.skip++
+	Default().Info(header, format, v...)
 }
 func InfoJson(header LogHeader, msg interface{}) {
-	_log.InfoJson(header, msg)
-}
-
-func (l *logger) Tag(header LogHeader, tag string, msg interface{}) {
-	_, file, line, _ := runtime.Caller(2) //calldepth=3
-	go func() {
-		if l.logLevel <= INFO {
-			switch logConfig.Mode {
-			case MOD_NORMAL:
-				logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.HostId)
-				l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[INFO] "+logHeader, msg)
-			case MOD_JSON:
-				o := &LogObject{
-					Timestamp: time.Now().Unix(),
-					Level:     Info_str,
-					Logid:     header.LogId,
-					Product:   header.Product,
-					Module:    header.Module,
-					Caller_ip: header.CallerIp,
-					Host_ip:   header.HostIp,
-					Msg:       msg,
-					Trace:     make(map[string]interface{}),
-					Tag:       tag,
-				}
-				o.Trace["File"] = shortFileName(file)
-				o.Trace["Line"] = line
-				l.objChan <- o
-			}
-		}
-	}()
+	header.skip++
+	Default().InfoJson(header, msg)
 }
 
-func (l *logger) InfoJson(header LogHeader, msg interface{}) {
-	_, file, line, _ := runtime.Caller(2) //calldepth=3
-	go func() {
-		if l.logLevel <= INFO {
-			switch logConfig.Mode {
-			case MOD_NORMAL:
-				logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.HostId)
-				l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[INFO] "+logHeader, msg)
-			case MOD_JSON:
-				o := &LogObject{
-					Timestamp: time.Now().Unix(),
-					Level:     Info_str,
-					Logid:     header.LogId,
-					Product:   header.Product,
-					Module:    header.Module,
-					Caller_ip: header.CallerIp,
-					Host_ip:   header.HostIp,
-					Msg:       msg,
-					Trace:     make(map[string]interface{}),
-				}
-				o.Trace["File"] = shortFileName(file)
-				o.Trace["Line"] = line
-				l.objChan <- o
-			}
-		}
-	}()
+func (l *BaseLogger) Tag(header LogHeader, tag string, msg interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(INFO, header.Module) && l.sample(INFO, file, line, tag) {
+		l.emit(INFO, header, file, line, tag, msg)
+	}
+}
+
+func (l *BaseLogger) InfoJson(header LogHeader, msg interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(INFO, header.Module) && l.sample(INFO, file, line, "") {
+		l.emit(INFO, header, file, line, "", msg)
+	}
 }
 
 // internal info log
-func (l *logger) Info(header LogHeader, format string, v ...interface{}) {
-	_, file, line, _ := runtime.Caller(3) //calldepth=3
-	if l.logLevel <= INFO {
-		switch logConfig.Mode {
-		case MOD_NORMAL:
-			logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.Module)
-			l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[INFO] "+logHeader+format, v...)
-		case MOD_JSON:
-			o := &LogObject{
-				Timestamp: time.Now().Unix(),
-				Level:     Info_str,
-				Logid:     header.LogId,
-				Product:   header.Product,
-				Module:    header.Module,
-				Caller_ip: header.CallerIp,
-				Host_ip:   header.HostIp,
-				Msg:       fmt.Sprintf(format, v...),
-				Trace:     make(map[string]interface{}),
-			}
-			o.Trace["File"] = shortFileName(file)
-			o.Trace["Line"] = line
-			l.objChan <- o
-		}
+func (l *BaseLogger) Info(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(INFO, header.Module) && l.sample(INFO, file, line, format) {
+		l.emit(INFO, header, file, line, "", fmt.Sprintf(format, v...))
+	}
+}
+
+// info log with key/value pairs
+func Infow(header LogHeader, msg string, kv ...interface{}) {
+	header.skip++
+	Default().Infow(header, msg, kv...)
+}
+
+func (l *BaseLogger) Infow(header LogHeader, msg string, kv ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(INFO, header.Module) && l.sample(INFO, file, line, msg) {
+		l.emit(INFO, header, file, line, "", msg, toFields(kv)...)
 	}
 }
 
 // debug log
 func Debug(header LogHeader, format string, v ...interface{}) {
-	_log.Debug(header, format, v...)
+	header.skip++
+	Default().Debug(header, format, v...)
 }
 
 // internal debug log
-func (l *logger) Debug(header LogHeader, format string, v ...interface{}) {
-	_, file, line, _ := runtime.Caller(2) //calldepth=3
-	if l.logLevel <= DEBUG {
-		switch logConfig.Mode {
-		case MOD_NORMAL:
-			logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.HostId)
-			l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[DEBUG] "+logHeader+format, v...)
-		case MOD_JSON:
-			o := &LogObject{
-				Timestamp: time.Now().Unix(),
-				Level:     Debug_str,
-				Logid:     header.LogId,
-				Product:   header.Product,
-				Module:    header.Module,
-				Caller_ip: header.CallerIp,
-				Host_ip:   header.HostIp,
-				Msg:       fmt.Sprintf(format, v...),
-				Trace:     make(map[string]interface{}),
-			}
-			o.Trace["File"] = shortFileName(file)
-			o.Trace["Line"] = line
-			l.objChan <- o
-		}
+func (l *BaseLogger) Debug(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(DEBUG, header.Module) && l.sample(DEBUG, file, line, format) {
+		l.emit(DEBUG, header, file, line, "", fmt.Sprintf(format, v...))
+	}
+}
+
+// debug log with key/value pairs
+func Debugw(header LogHeader, msg string, kv ...interface{}) {
+	header.skip++
+	Default().Debugw(header, msg, kv...)
+}
+
+func (l *BaseLogger) Debugw(header LogHeader, msg string, kv ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(DEBUG, header.Module) && l.sample(DEBUG, file, line, msg) {
+		l.emit(DEBUG, header, file, line, "", msg, toFields(kv)...)
 	}
 }
 
 // warn log
 func Warn(header LogHeader, format string, v ...interface{}) {
-	_log.Warn(header, format, v...)
+	header.skip++
+	Default().Warn(header, format, v...)
 }
 
 // internal warn log
-func (l *logger) Warn(header LogHeader, format string, v ...interface{}) {
-	_, file, line, _ := runtime.Caller(2) //calldepth=3
-	if l.logLevel <= WARN {
-		switch logConfig.Mode {
-		case MOD_NORMAL:
-			logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.HostId)
-			l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[WARN] "+logHeader+format, v...)
-		case MOD_JSON:
-			o := &LogObject{
-				Timestamp: time.Now().Unix(),
-				Level:     Warn_str,
-				Logid:     header.LogId,
-				Product:   header.Product,
-				Module:    header.Module,
-				Caller_ip: header.CallerIp,
-				Host_ip:   header.HostIp,
-				Msg:       fmt.Sprintf(format, v...),
-				Trace:     make(map[string]interface{}),
-			}
-			o.Trace["File"] = shortFileName(file)
-			o.Trace["Line"] = line
-			l.objChan <- o
-		}
+func (l *BaseLogger) Warn(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(WARN, header.Module) && l.sample(WARN, file, line, format) {
+		l.emit(WARN, header, file, line, "", fmt.Sprintf(format, v...))
+	}
+}
+
+// warn log with key/value pairs
+func Warnw(header LogHeader, msg string, kv ...interface{}) {
+	header.skip++
+	Default().Warnw(header, msg, kv...)
+}
+
+func (l *BaseLogger) Warnw(header LogHeader, msg string, kv ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(WARN, header.Module) && l.sample(WARN, file, line, msg) {
+		l.emit(WARN, header, file, line, "", msg, toFields(kv)...)
 	}
 }
 
 // error log
 func Error(header LogHeader, format string, v ...interface{}) {
-	_log.Error(header, format, v...)
+	header.skip++
+	Default().Error(header, format, v...)
 }
 
 // internal error log
-func (l *logger) Error(header LogHeader, format string, v ...interface{}) {
-	_, file, line, _ := runtime.Caller(2) //calldepth=3
-	if l.logLevel <= ERROR {
-		switch logConfig.Mode {
-		case MOD_NORMAL:
-			logHeader := fmt.Sprintf("[%s][%s][%s] MSG:", header.LogId, header.ReqId, header.Module)
-			l.logChan <- fmt.Sprintf("[%v:%v]", shortFileName(file), line) + fmt.Sprintf("[ERROR] "+logHeader+format, v...)
-		case MOD_JSON:
-			o := &LogObject{
-				Timestamp: time.Now().Unix(),
-				Level:     Error_str,
-				Logid:     header.LogId,
-				Product:   header.Product,
-				Module:    header.Module,
-				Caller_ip: header.CallerIp,
-				Host_ip:   header.HostIp,
-				Msg:       fmt.Sprintf(format, v...),
-				Trace:     make(map[string]interface{}),
-			}
-			o.Trace["File"] = shortFileName(file)
-			o.Trace["Line"] = line
-			l.objChan <- o
+func (l *BaseLogger) Error(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(ERROR, header.Module) && l.sample(ERROR, file, line, format) {
+		if hasError(v) {
+			header.stack = l.stackOf(header)
 		}
+		l.emit(ERROR, header, file, line, "", fmt.Sprintf(format, v...))
 	}
 }
 
+// error log with key/value pairs
+func Errorw(header LogHeader, msg string, kv ...interface{}) {
+	header.skip++
+	Default().Errorw(header, msg, kv...)
+}
+
+func (l *BaseLogger) Errorw(header LogHeader, msg string, kv ...interface{}) {
+	file, line := l.caller(header)
+	if l.enabled(ERROR, header.Module) && l.sample(ERROR, file, line, msg) {
+		if hasError(kv) {
+			header.stack = l.stackOf(header)
+		}
+		l.emit(ERROR, header, file, line, "", msg, toFields(kv)...)
+	}
+}
+
+// panic log, panics with the message once the entry is flushed
+func Panic(header LogHeader, format string, v ...interface{}) {
+	header.skip++
+	Default().Panic(header, format, v...)
+}
+
+func (l *BaseLogger) Panic(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	msg := fmt.Sprintf(format, v...)
+	l.emit(PANIC, header, file, line, "", msg)
+	l.Sync()
+	panic(msg)
+}
+
+// fatal log, exits the process once the logger is shut down
+func Fatal(header LogHeader, format string, v ...interface{}) {
+	header.skip++
+	Default().Fatal(header, format, v...)
+}
+
+func (l *BaseLogger) Fatal(header LogHeader, format string, v ...interface{}) {
+	file, line := l.caller(header)
+	l.emit(FATAL, header, file, line, "", fmt.Sprintf(format, v...))
+	l.Shutdown(context.Background())
+	exit(1)
+}
+
+// exit is replaced in tests.
+var exit = os.Exit
+
 func isExist(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil || os.IsExist(err)
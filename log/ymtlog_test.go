@@ -1,11 +1,16 @@
 package log
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
-var tl *logger = NewLogger("./", "test.log")
+var tl *BaseLogger = NewLogger("./", "test.log")
 
 func Test_rotate(t *testing.T) {
 	for i := 0; i < 10; i++ {
@@ -22,3 +27,171 @@ func Test_checkFile_2(t *testing.T) {
 	tl.date = time.Now().Add((-24) * time.Hour)
 	tl.checkFile()
 }
+
+func Test_rotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	l := newLogger(&LogConfig{Path: dir, Name: "size.log", Rotate: ROTATE_SIZE, MaxSize: 100})
+	for i := 0; i < 6; i++ {
+		l.Write(INFO, []byte(strings.Repeat("x", 49)+"\n"))
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "size.log."+time.Now().Format(DATEFORMAT)+"*"))
+	if len(backups) != 2 {
+		t.Errorf("expect 2 backups, got %v", backups)
+	}
+}
+
+func Test_rotateHourly(t *testing.T) {
+	l := newLogger(&LogConfig{Path: t.TempDir(), Name: "hour.log", Rotate: ROTATE_HOURLY})
+	if l.isNeedRotate() {
+		t.Errorf("fresh file should not rotate")
+	}
+	l.date = l.date.Add(-time.Hour)
+	if !l.isNeedRotate() {
+		t.Errorf("file of last hour should rotate")
+	}
+}
+
+func Test_cleanBackups(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, "clean.log")
+	l.conf.MaxBackups = 2
+	l.conf.Compress = true
+	for i := 0; i < 4; i++ {
+		l.Write(INFO, []byte("line\n"))
+		l.rotate()
+	}
+	l.cleanBackups()
+	files := l.backups()
+	if len(files) != 2 {
+		t.Fatalf("expect 2 backups, got %v", files)
+	}
+	for _, f := range files {
+		if filepath.Ext(f.path) != compressSuffix {
+			t.Errorf("%s is not compressed", f.path)
+		}
+	}
+}
+
+func Test_rotateCompressed(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, "gz.log")
+	l.conf.Compress = true
+	for i := 0; i < 3; i++ {
+		l.Write(INFO, []byte("line\n"))
+		l.rotate()
+		//the compressed backup must not be reused by the next rotation
+		l.cleanBackups()
+	}
+	if files := l.backups(); len(files) != 3 {
+		t.Errorf("expect 3 backups, got %v", files)
+	}
+}
+
+func Test_cleanBackupsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, "app")
+	l.conf.MaxBackups = 1
+	l.conf.Compress = true
+	other := filepath.Join(dir, "app.access")
+	os.WriteFile(other, []byte("line\n"), 0644)
+	l.cleanBackups()
+	if !isExist(other) {
+		t.Errorf("%s is not a backup of app", other)
+	}
+}
+
+func Test_compressFileExisting(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "c.log."+time.Now().Format(DATEFORMAT))
+	os.WriteFile(src, []byte("new\n"), 0644)
+	os.WriteFile(src+compressSuffix, []byte("old"), 0644)
+	if err := compressFile(src); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expect ErrExist, got %v", err)
+	}
+	if data, _ := os.ReadFile(src + compressSuffix); string(data) != "old" {
+		t.Errorf("existing backup overwritten")
+	}
+	if !isExist(src) {
+		t.Errorf("%s removed", src)
+	}
+}
+
+func Test_Shutdown(t *testing.T) {
+	dir := t.TempDir()
+	l := newLogger(&LogConfig{Path: dir, Name: "shutdown.json", Mode: MOD_JSON})
+	for i := 0; i < 100; i++ {
+		l.Tag(LogHeader{LogId: "shutdown"}, "tag", i)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync failed:%s", err.Error())
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed:%s", err.Error())
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second shutdown failed:%s", err.Error())
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "shutdown.json"))
+	if n := strings.Count(string(data), "\n"); n != 100 {
+		t.Errorf("expect 100 lines, got %d", n)
+	}
+}
+
+func Test_panicAndFatal(t *testing.T) {
+	mem := &memSink{}
+	l := newLogger(&LogConfig{Sink: mem, Level: ERROR})
+	func() {
+		defer func() {
+			if r := recover(); r != "boom 1" {
+				t.Errorf("unexpected panic value %v", r)
+			}
+		}()
+		l.Panic(LogHeader{}, "boom %d", 1)
+	}()
+	code := 0
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+	l.Fatal(LogHeader{}, "bye")
+	if code != 1 {
+		t.Errorf("expect exit code 1, got %d", code)
+	}
+	if len(mem.lines) != 2 || !strings.Contains(mem.lines[0], "[PANIC]") || !strings.Contains(mem.lines[1], "[FATAL]") {
+		t.Errorf("unexpected lines %v", mem.lines)
+	}
+}
+
+// panicSink panics on its first write.
+type panicSink struct {
+	memSink
+	panicked bool
+}
+
+func (s *panicSink) Write(level int, p []byte) error {
+	if !s.panicked {
+		s.panicked = true
+		panic("sink broken")
+	}
+	return s.memSink.Write(level, p)
+}
+
+func Test_writePanic(t *testing.T) {
+	sink := &panicSink{}
+	var reported []error
+	l := newLogger(&LogConfig{Sink: sink, OnError: func(err error) { reported = append(reported, err) }})
+	l.Info(LogHeader{}, "lost")
+	l.Info(LogHeader{}, "written")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync failed:%s", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed:%s", err.Error())
+	}
+	if len(sink.lines) != 1 || !strings.Contains(sink.lines[0], "written") {
+		t.Errorf("unexpected lines %v", sink.lines)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "sink broken") {
+		t.Errorf("unexpected errors %v", reported)
+	}
+}